@@ -0,0 +1,248 @@
+// Package bench は各スクリプトエンジンのコンパイル時間・実行時間・メモリ割り当てを計測する。
+//
+// 計測する処理（CompileOp・RunOp）は go test -bench . ./bench のベンチマークと共有し、
+// 結果はREADMEのパフォーマンス表と同じ形のMarkdownで出力できる。
+package bench
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"time"
+
+	"github.com/suinplayground/golang-embedded-scripting/kube"
+	"github.com/suinplayground/golang-embedded-scripting/scripting"
+)
+
+// DefaultSizes は入力ConfigMap数のデフォルト（5件〜10万件）
+var DefaultSizes = []int{5, 100, 1_000, 10_000, 100_000}
+
+// Target は計測対象のエンジンとスクリプトの組
+type Target struct {
+	// Label は表の列見出し（例: "TypeScript"）
+	Label  string
+	Engine scripting.Engine
+	Script scripting.Script
+}
+
+// Options は計測の設定
+type Options struct {
+	// Sizes は入力ConfigMap数のリスト（小さい順）
+	Sizes []int
+
+	// MaxRunTime は1回の実行にかかる見込み時間の上限。
+	// 前のサイズの実行時間から見積もって超えそうなサイズはスキップする（0なら無制限）
+	MaxRunTime time.Duration
+
+	// BenchTime は1つの処理を繰り返し実行する時間の目安（0なら1秒、go test -bench と同じ）
+	BenchTime time.Duration
+
+	// Progress は計測の進捗を受け取る（nilなら何もしない）
+	Progress func(format string, args ...interface{})
+}
+
+// Result は1つのエンジンの計測結果
+type Result struct {
+	Label string
+	// Err はコンパイル・実行時のエラー
+	Err error
+	// Compile はスクリプトのコンパイル（トランスパイル・パース）の計測結果
+	Compile Measurement
+	// Runs は入力サイズごとの実行の計測結果（スキップしたサイズは含まない）
+	Runs map[int]Measurement
+}
+
+// Measurement は1つの処理を繰り返し実行した計測結果
+type Measurement struct {
+	// N は実行回数
+	N int
+	// T は N 回の合計時間
+	T time.Duration
+	// Bytes は N 回の合計のヒープ割り当てバイト数
+	Bytes uint64
+	// Allocs は N 回の合計のヒープ割り当て回数
+	Allocs uint64
+}
+
+// NsPerOp は1回あたりの時間（ナノ秒）
+func (m Measurement) NsPerOp() int64 {
+	if m.N == 0 {
+		return 0
+	}
+	return m.T.Nanoseconds() / int64(m.N)
+}
+
+// AllocedBytesPerOp は1回あたりの割り当てバイト数
+func (m Measurement) AllocedBytesPerOp() int64 {
+	if m.N == 0 {
+		return 0
+	}
+	return int64(m.Bytes) / int64(m.N)
+}
+
+// AllocsPerOp は1回あたりの割り当て回数
+func (m Measurement) AllocsPerOp() int64 {
+	if m.N == 0 {
+		return 0
+	}
+	return int64(m.Allocs) / int64(m.N)
+}
+
+// Run は各ターゲットのコンパイルと、入力サイズごとの実行を計測する
+//
+// ctxが終了すると残りの計測は行わない。
+func Run(ctx context.Context, targets []Target, opts Options) []Result {
+	if len(opts.Sizes) == 0 {
+		opts.Sizes = DefaultSizes
+	}
+	if opts.BenchTime <= 0 {
+		opts.BenchTime = time.Second
+	}
+	progress := opts.Progress
+	if progress == nil {
+		progress = func(string, ...interface{}) {}
+	}
+
+	inputs := make(map[int][]kube.Object, len(opts.Sizes))
+	for _, size := range opts.Sizes {
+		inputs[size] = GenerateConfigMaps(size)
+	}
+
+	results := make([]Result, 0, len(targets))
+	for _, t := range targets {
+		r := Result{Label: t.Label, Runs: map[int]Measurement{}}
+
+		if err := ctx.Err(); err != nil {
+			r.Err = err
+			results = append(results, r)
+			continue
+		}
+
+		program, err := t.Engine.Compile(ctx, t.Script)
+		if err != nil {
+			r.Err = err
+			results = append(results, r)
+			continue
+		}
+
+		progress("%s: コンパイル", t.Label)
+		if r.Compile, err = Measure(CompileOp(t.Engine, t.Script), opts.BenchTime); err != nil {
+			r.Err = err
+			results = append(results, r)
+			continue
+		}
+
+		var prevSize int
+		var prevTime time.Duration
+		for _, size := range opts.Sizes {
+			if opts.MaxRunTime > 0 && prevSize > 0 {
+				estimate := time.Duration(float64(prevTime) * float64(size) / float64(prevSize))
+				if estimate > opts.MaxRunTime {
+					progress("%s: %d件 スキップ（見込み %s）", t.Label, size, estimate.Round(time.Second))
+					break
+				}
+			}
+
+			// 1回実行してエラーがないことを確認する
+			if _, err := program.Run(ctx, inputs[size]); err != nil {
+				r.Err = fmt.Errorf("%d件: %w", size, err)
+				break
+			}
+
+			progress("%s: %d件", t.Label, size)
+			m, err := Measure(RunOp(program, inputs[size]), opts.BenchTime)
+			if err != nil {
+				r.Err = fmt.Errorf("%d件: %w", size, err)
+				break
+			}
+			r.Runs[size] = m
+			prevSize, prevTime = size, time.Duration(m.NsPerOp())
+		}
+		results = append(results, r)
+	}
+	return results
+}
+
+// CompileOp はスクリプトのコンパイル1回の処理を返す
+func CompileOp(engine scripting.Engine, script scripting.Script) func() error {
+	return func() error {
+		_, err := engine.Compile(context.Background(), script)
+		return err
+	}
+}
+
+// RunOp はコンパイル済みスクリプトの実行1回の処理を返す（入力の変換を含む）
+func RunOp(program scripting.Program, input []kube.Object) func() error {
+	return func() error {
+		_, err := program.Run(context.Background(), input)
+		return err
+	}
+}
+
+// maxN は1回の計測で op を実行する回数の上限
+const maxN = 1_000_000_000
+
+// Measure は op を合計 benchTime 程度になるまで繰り返し実行して計測する
+//
+// testing.B と同じく、1回から始めて前回の時間から回数を見積もりながら増やす。
+func Measure(op func() error, benchTime time.Duration) (Measurement, error) {
+	n := 1
+	for {
+		m, err := measureN(op, n)
+		if err != nil || m.T >= benchTime || n >= maxN {
+			return m, err
+		}
+		// 見積もりより少し多めにし、1回で100倍を超えては増やさない
+		next := int(float64(benchTime) / float64(max(m.T.Nanoseconds(), 1)) * float64(n) * 1.2)
+		n = min(max(next, n+1), 100*n, maxN)
+	}
+}
+
+// measureN は op を n 回実行した時間とヒープ割り当てを計測する
+func measureN(op func() error, n int) (Measurement, error) {
+	runtime.GC()
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+	for i := 0; i < n; i++ {
+		if err := op(); err != nil {
+			return Measurement{}, err
+		}
+	}
+	elapsed := time.Since(start)
+	runtime.ReadMemStats(&after)
+	return Measurement{
+		N:      n,
+		T:      elapsed,
+		Bytes:  after.TotalAlloc - before.TotalAlloc,
+		Allocs: after.Mallocs - before.Mallocs,
+	}, nil
+}
+
+// GenerateConfigMaps はn件のサブネット情報のConfigMapを生成する
+//
+// VPCは10個（n < 10ならn個）に均等に分散する。
+func GenerateConfigMaps(n int) []kube.Object {
+	vpcs := min(n, 10)
+	objects := make([]kube.Object, n)
+	for i := range objects {
+		objects[i] = kube.Object{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata": map[string]interface{}{
+				"name":      fmt.Sprintf("subnet-%06d", i),
+				"namespace": "default",
+				"labels": map[string]interface{}{
+					"vpc-id": fmt.Sprintf("vpc-%05d", i%vpcs),
+					"az":     fmt.Sprintf("ap-northeast-1%c", 'a'+rune(i%3)),
+				},
+			},
+			"data": map[string]interface{}{
+				"subnet-id":   fmt.Sprintf("subnet-%08x", i),
+				"cidr-block":  fmt.Sprintf("10.%d.%d.0/24", i/256%256, i%256),
+				"description": fmt.Sprintf("Subnet %d", i),
+			},
+		}
+	}
+	return objects
+}
@@ -0,0 +1,124 @@
+package bench_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/suinplayground/golang-embedded-scripting/bench"
+	"github.com/suinplayground/golang-embedded-scripting/examples"
+	"github.com/suinplayground/golang-embedded-scripting/scripting"
+	"github.com/suinplayground/golang-embedded-scripting/scripting/cuelang"
+	"github.com/suinplayground/golang-embedded-scripting/scripting/starlark"
+	"github.com/suinplayground/golang-embedded-scripting/scripting/typescript"
+)
+
+// benchSizes は go test -bench で計測する入力ConfigMap数（大きいサイズは embedscript bench で計測する）
+var benchSizes = []int{5, 100, 1_000}
+
+// targets はexamples/のVPC別グループ化スクリプトと各エンジンの組を返す
+func targets(tb testing.TB) []bench.Target {
+	tb.Helper()
+	labels := map[string]string{
+		typescript.EngineName: "TypeScript",
+		starlark.EngineName:   "Starlark",
+		cuelang.EngineName:    "CUE",
+	}
+	var targets []bench.Target
+	for _, engine := range []scripting.Engine{typescript.New(), starlark.New(), cuelang.New()} {
+		script, err := examples.VPCProcessor(engine.Name())
+		if err != nil {
+			tb.Fatal(err)
+		}
+		targets = append(targets, bench.Target{Label: labels[engine.Name()], Engine: engine, Script: script})
+	}
+	return targets
+}
+
+func loop(b *testing.B, op func() error) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := op(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkCompile(b *testing.B) {
+	for _, t := range targets(b) {
+		b.Run(t.Label, func(b *testing.B) {
+			loop(b, bench.CompileOp(t.Engine, t.Script))
+		})
+	}
+}
+
+func BenchmarkRun(b *testing.B) {
+	for _, t := range targets(b) {
+		program, err := t.Engine.Compile(context.Background(), t.Script)
+		if err != nil {
+			b.Fatal(err)
+		}
+		for _, size := range benchSizes {
+			input := bench.GenerateConfigMaps(size)
+			b.Run(fmt.Sprintf("%s/%d", t.Label, size), func(b *testing.B) {
+				loop(b, bench.RunOp(program, input))
+			})
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	results := bench.Run(context.Background(), targets(t), bench.Options{
+		Sizes:     []int{5},
+		BenchTime: 10 * time.Millisecond,
+	})
+	for _, r := range results {
+		if r.Err != nil {
+			t.Fatalf("%s: %v", r.Label, r.Err)
+		}
+		if r.Compile.N == 0 || r.Runs[5].N == 0 || r.Runs[5].AllocsPerOp() == 0 {
+			t.Fatalf("%s: 計測されていません: %+v", r.Label, r)
+		}
+	}
+
+	var b strings.Builder
+	if err := bench.WriteTable(&b, []int{5}, results); err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range []string{"| 項目 | TypeScript | Starlark | CUE |", "| **起動時間** |", "| **実行速度（5件）** |", "| **メモリ使用量（5件）** |"} {
+		if !strings.Contains(b.String(), row) {
+			t.Errorf("表に %q がありません:\n%s", row, b.String())
+		}
+	}
+}
+
+func TestMeasure(t *testing.T) {
+	tests := []struct {
+		name      string
+		op        func() error
+		benchTime time.Duration
+		wantErr   bool
+	}{
+		{name: "繰り返す", op: func() error { time.Sleep(time.Millisecond); return nil }, benchTime: 20 * time.Millisecond},
+		{name: "エラー", op: func() error { return fmt.Errorf("失敗") }, benchTime: time.Second, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := bench.Measure(tt.op, tt.benchTime)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("エラーになりませんでした")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if m.N < 2 || m.T < tt.benchTime {
+				t.Fatalf("N=%d T=%s, want N >= 2, T >= %s", m.N, m.T, tt.benchTime)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package bench
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// WriteTable は計測結果をREADMEのパフォーマンス表と同じ形のMarkdownで書き出す
+//
+//	| 項目 | TypeScript | Starlark | CUE |
+//	|------|-----------|----------|-----|
+//	| **起動時間** | ... |
+//	| **実行速度（5件）** | ... |
+//	| **メモリ使用量（5件）** | ... |
+func WriteTable(w io.Writer, sizes []int, results []Result) error {
+	if len(sizes) == 0 {
+		sizes = DefaultSizes
+	}
+
+	var b strings.Builder
+	b.WriteString("| 項目 |")
+	for _, r := range results {
+		fmt.Fprintf(&b, " %s |", r.Label)
+	}
+	b.WriteString("\n|------|")
+	for range results {
+		b.WriteString("------|")
+	}
+	b.WriteString("\n")
+
+	row := func(label string, cell func(Result) string) {
+		fmt.Fprintf(&b, "| **%s** |", label)
+		for _, r := range results {
+			fmt.Fprintf(&b, " %s |", cell(r))
+		}
+		b.WriteString("\n")
+	}
+
+	row("起動時間", func(r Result) string {
+		if r.Compile.N == 0 {
+			return "-"
+		}
+		return formatDuration(r.Compile.NsPerOp())
+	})
+	for _, size := range sizes {
+		row(fmt.Sprintf("実行速度（%s件）", formatCount(size)), func(r Result) string {
+			br, ok := r.Runs[size]
+			if !ok {
+				return "-"
+			}
+			return formatDuration(br.NsPerOp())
+		})
+	}
+	for _, size := range sizes {
+		row(fmt.Sprintf("メモリ使用量（%s件）", formatCount(size)), func(r Result) string {
+			br, ok := r.Runs[size]
+			if !ok {
+				return "-"
+			}
+			return formatMemory(br)
+		})
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func formatDuration(ns int64) string {
+	d := time.Duration(ns)
+	switch {
+	case d >= time.Second:
+		return fmt.Sprintf("%.2fs", d.Seconds())
+	case d >= time.Millisecond:
+		return fmt.Sprintf("%.2fms", float64(d)/float64(time.Millisecond))
+	default:
+		return fmt.Sprintf("%.1fµs", float64(d)/float64(time.Microsecond))
+	}
+}
+
+func formatMemory(br Measurement) string {
+	bytes := float64(br.AllocedBytesPerOp())
+	var size string
+	switch {
+	case bytes >= 1<<30:
+		size = fmt.Sprintf("%.2fGB", bytes/(1<<30))
+	case bytes >= 1<<20:
+		size = fmt.Sprintf("%.1fMB", bytes/(1<<20))
+	default:
+		size = fmt.Sprintf("%.1fKB", bytes/(1<<10))
+	}
+	return fmt.Sprintf("%s（%s allocs）", size, formatCount(int(br.AllocsPerOp())))
+}
+
+// 3桁区切りの数値
+func formatCount(n int) string {
+	s := fmt.Sprint(n)
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return s
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/suinplayground/golang-embedded-scripting/bench"
+	"github.com/suinplayground/golang-embedded-scripting/scripting"
+	"github.com/suinplayground/golang-embedded-scripting/scripting/cuelang"
+	"github.com/suinplayground/golang-embedded-scripting/scripting/starlark"
+	"github.com/suinplayground/golang-embedded-scripting/scripting/typescript"
+)
+
+// 表の列見出し
+var engineLabels = map[string]string{
+	typescript.EngineName: "TypeScript",
+	starlark.EngineName:   "Starlark",
+	cuelang.EngineName:    "CUE",
+}
+
+// embedscript bench
+func benchCommand(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("bench", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "使い方: embedscript bench [--sizes 5,100,...] [--engines ts,starlark,cue] [--max-run-time 1m] [--cache N]")
+		fmt.Fprintln(stderr)
+		fs.PrintDefaults()
+	}
+
+	var (
+		sizesFlag   string
+		enginesFlag string
+		maxRunTime  time.Duration
+		cacheSize   int
+	)
+	fs.StringVar(&sizesFlag, "sizes", "5,100,1000,10000,100000", "入力ConfigMap数（カンマ区切り）")
+	fs.StringVar(&enginesFlag, "engines", "ts,starlark,cue", "計測するエンジン（カンマ区切り）")
+	fs.DurationVar(&maxRunTime, "max-run-time", time.Minute, "1回の実行の見込み時間がこれを超えるサイズはスキップする（0で無制限）")
+	fs.IntVar(&cacheSize, "cache", 0, "コンパイル済みのプログラムをキャッシュする件数（0でキャッシュしない）。コンパイルの列はキャッシュから取り出す時間になる")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var sizes []int
+	for _, s := range strings.Split(sizesFlag, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(strings.ReplaceAll(s, "_", "")))
+		if err != nil || n <= 0 {
+			return fmt.Errorf("--sizes が不正です: %q", s)
+		}
+		sizes = append(sizes, n)
+	}
+
+	var cache *scripting.Cache
+	if cacheSize > 0 {
+		cache = scripting.NewCache(cacheSize)
+	}
+
+	var targets []bench.Target
+	for _, e := range strings.Split(enginesFlag, ",") {
+		name, err := engineName(strings.TrimSpace(e), "")
+		if err != nil {
+			return err
+		}
+		script, err := loadScript(name, "")
+		if err != nil {
+			return err
+		}
+		targets = append(targets, bench.Target{
+			Label:  engineLabels[name],
+			Engine: newEngine(name, nil, moduleConfig{}, cache, scripting.Limits{}),
+			Script: script,
+		})
+	}
+
+	results := bench.Run(ctx, targets, bench.Options{
+		Sizes:      sizes,
+		MaxRunTime: maxRunTime,
+		Progress: func(format string, args ...interface{}) {
+			fmt.Fprintf(stderr, "⏱  "+format+"\n", args...)
+		},
+	})
+
+	var failed bool
+	for _, r := range results {
+		if r.Err != nil {
+			fmt.Fprintf(stderr, "エラー: %s: %v\n", r.Label, r.Err)
+			failed = true
+		}
+	}
+
+	if err := bench.WriteTable(stdout, sizes, results); err != nil {
+		return err
+	}
+	if cache != nil {
+		s := cache.Stats()
+		fmt.Fprintf(stderr, "キャッシュ: ヒット %d / ミス %d（追い出し %d、%d/%d 件）\n", s.Hits, s.Misses, s.Evictions, s.Entries, s.MaxEntries)
+	}
+	if failed {
+		return fmt.Errorf("計測できなかったエンジンがあります")
+	}
+	return nil
+}
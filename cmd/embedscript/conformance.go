@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/suinplayground/golang-embedded-scripting/conformance"
+	"github.com/suinplayground/golang-embedded-scripting/examples"
+	"github.com/suinplayground/golang-embedded-scripting/scripting"
+	"github.com/suinplayground/golang-embedded-scripting/scripting/cuelang"
+	"github.com/suinplayground/golang-embedded-scripting/scripting/starlark"
+	"github.com/suinplayground/golang-embedded-scripting/scripting/typescript"
+)
+
+// embedscript conformance
+func conformanceCommand(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("conformance", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "使い方: embedscript conformance [--vectors DIR] [--ts FILE] [--starlark FILE] [--cue FILE] [--timeout 1m]")
+		fmt.Fprintln(stderr)
+		fs.PrintDefaults()
+	}
+
+	var (
+		vectorsDir string
+		verbose    bool
+		timeout    time.Duration
+	)
+	scriptPaths := map[string]*string{
+		typescript.EngineName: fs.String("ts", "", "TypeScriptスクリプト（省略時は組み込みの examples/vpc-processor.ts）"),
+		starlark.EngineName:   fs.String("starlark", "", "Starlarkスクリプト（省略時は組み込みの examples/vpc-processor.star）"),
+		cuelang.EngineName:    fs.String("cue", "", "CUEスクリプト（省略時は組み込みの examples/vpc-processor.cue）"),
+	}
+	fs.StringVar(&vectorsDir, "vectors", "", "テストベクタのディレクトリ（省略時は組み込みのベクタ）")
+	fs.BoolVar(&verbose, "v", false, "スクリプトのログを標準エラーに表示する")
+	fs.DurationVar(&timeout, "timeout", time.Minute, "すべてのケースの実行の制限時間（0で無制限）")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	vectorsFS := conformance.DefaultVectors()
+	if vectorsDir != "" {
+		vectorsFS = os.DirFS(vectorsDir)
+	}
+	vectors, err := conformance.LoadVectors(vectorsFS)
+	if err != nil {
+		return err
+	}
+
+	var logs slog.Handler
+	if verbose {
+		logs = newLogHandler(stderr)
+	}
+
+	var targets []conformance.Target
+	for _, name := range []string{typescript.EngineName, starlark.EngineName, cuelang.EngineName} {
+		script, err := loadScript(name, *scriptPaths[name])
+		if err != nil {
+			return err
+		}
+		targets = append(targets, conformance.Target{
+			Engine: newEngine(name, logs, moduleConfig{}, nil, scripting.Limits{}),
+			Script: script,
+		})
+	}
+
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	report := conformance.Run(ctx, vectors, targets)
+	printReport(stdout, report)
+
+	if !report.Passed() {
+		return fmt.Errorf("期待値と異なる結果がありました")
+	}
+	return nil
+}
+
+// loadScript はファイルからスクリプトを読み込む（pathが空なら組み込みのサンプル）
+func loadScript(engine, path string) (scripting.Script, error) {
+	if path == "" {
+		return examples.VPCProcessor(engine)
+	}
+	return readScript(path, "")
+}
+
+func printReport(w io.Writer, report *conformance.Report) {
+	for _, c := range report.Cases {
+		fmt.Fprintf(w, "=== %s\n", c.Vector)
+		for _, e := range c.Engines {
+			if e.Passed() {
+				fmt.Fprintf(w, "  ✅ %s\n", e.Engine)
+				continue
+			}
+			fmt.Fprintf(w, "  ❌ %s\n", e.Engine)
+			if e.Err != nil {
+				fmt.Fprintf(w, "      エラー: %v\n", e.Err)
+			}
+			for _, d := range e.Diffs {
+				fmt.Fprintf(w, "      %s\n", d)
+			}
+		}
+	}
+}
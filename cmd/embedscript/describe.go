@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/suinplayground/golang-embedded-scripting/scripting"
+)
+
+// embedscript describe
+func describeCommand(args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("describe", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "使い方: embedscript describe [--engine ts|starlark|cue] [--params-file FILE] [--output text|json] SCRIPT")
+		fmt.Fprintln(stderr)
+		fs.PrintDefaults()
+	}
+
+	var (
+		engineFlag string
+		paramsFile string
+		output     string
+	)
+	fs.StringVar(&engineFlag, "engine", "", "スクリプトエンジン（ts, starlark, cue）。省略時はスクリプトの拡張子から判定")
+	fs.StringVar(&paramsFile, "params-file", "", "パラメータの宣言ファイル（省略時はスクリプトと同じ名前の .params.yaml があれば使う）")
+	fs.StringVar(&output, "output", "text", "出力形式（text, json）")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("スクリプトを1つ指定してください")
+	}
+	scriptPath := fs.Arg(0)
+
+	name, err := engineName(engineFlag, scriptPath)
+	if err != nil {
+		return err
+	}
+	script, err := readScript(scriptPath, paramsFile)
+	if err != nil {
+		return err
+	}
+	// 宣言だけを読む（スクリプトはコンパイルしない）
+	params, err := scripting.DeclaredParams(name, script, engineLineComments[name])
+	if err != nil {
+		return err
+	}
+
+	switch output {
+	case "text":
+		return describeText(stdout, script.Name, name, params)
+	case "json":
+		return describeJSON(stdout, script.Name, name, params)
+	default:
+		return fmt.Errorf("不明な出力形式: %s（text, json のいずれかを指定してください）", output)
+	}
+}
+
+func describeText(w io.Writer, script, engine string, params []scripting.Param) error {
+	fmt.Fprintf(w, "スクリプト: %s（%s）\n", script, engine)
+	if len(params) == 0 {
+		fmt.Fprintln(w, "パラメータ: なし")
+		return nil
+	}
+
+	// 名前・型・デフォルト値・説明（全角文字は揃わないので説明は最後の列にする）
+	fmt.Fprintln(w, "パラメータ:")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, p := range params {
+		def, desc := "-", p.Description
+		if p.Required() {
+			desc = strings.TrimSpace("（必須）" + desc)
+		} else {
+			def = "= " + formatDefault(p.Default)
+		}
+		line := fmt.Sprintf("  %s\t%s\t%s", p.Name, p.Type, def)
+		if desc != "" {
+			line += "\t" + desc
+		}
+		fmt.Fprintln(tw, line)
+	}
+	return tw.Flush()
+}
+
+// formatDefault はデフォルト値を表示する（文字列は引用符で囲む）
+func formatDefault(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return fmt.Sprintf("%q", s)
+	}
+	return fmt.Sprint(v)
+}
+
+// describe --output json の出力
+type jsonDescription struct {
+	Script string      `json:"script"`
+	Engine string      `json:"engine"`
+	Params []jsonParam `json:"params"`
+}
+
+type jsonParam struct {
+	Name        string      `json:"name"`
+	Type        string      `json:"type"`
+	Required    bool        `json:"required"`
+	Default     interface{} `json:"default,omitempty"`
+	Description string      `json:"description,omitempty"`
+}
+
+func describeJSON(w io.Writer, script, engine string, params []scripting.Param) error {
+	desc := jsonDescription{Script: script, Engine: engine, Params: []jsonParam{}}
+	for _, p := range params {
+		desc.Params = append(desc.Params, jsonParam{
+			Name:        p.Name,
+			Type:        string(p.Type),
+			Required:    p.Required(),
+			Default:     p.Default,
+			Description: p.Description,
+		})
+	}
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(desc)
+}
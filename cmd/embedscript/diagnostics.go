@@ -0,0 +1,184 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+
+	"github.com/suinplayground/golang-embedded-scripting/scripting"
+)
+
+// errReported は診断をすでに出力したエラー（main はメッセージを出さずに終了する）
+var errReported = errors.New("スクリプトのエラーを診断として出力しました")
+
+// 診断の出力形式
+const (
+	diagnosticsText  = "text"
+	diagnosticsJSONL = "jsonl"
+	diagnosticsSARIF = "sarif"
+)
+
+// reporter はスクリプトの診断を --diagnostics-format の形式で書き出す
+type reporter struct {
+	format     string
+	w          io.Writer
+	engine     string
+	script     string // 診断の Pos.File に入っているスクリプト名
+	scriptPath string // 出力するファイルのパス
+	moduleRoot string // load()・import したモジュールのパスの基準のディレクトリ
+}
+
+func newReporter(format string, w io.Writer, engine, script, scriptPath, moduleRoot string) (*reporter, error) {
+	switch format {
+	case diagnosticsText, diagnosticsJSONL, diagnosticsSARIF:
+	default:
+		return nil, fmt.Errorf("不明な診断の出力形式: %s（text, jsonl, sarif のいずれかを指定してください）", format)
+	}
+	return &reporter{
+		format:     format,
+		w:          w,
+		engine:     engine,
+		script:     script,
+		scriptPath: scriptPath,
+		moduleRoot: moduleRoot,
+	}, nil
+}
+
+// fail はスクリプトのエラーを出力する
+//
+// text形式ではエラーをそのまま返し（main が表示する）、
+// それ以外では診断を書き出して errReported を返す。
+func (r *reporter) fail(err error) error {
+	if r.format == diagnosticsText {
+		return err
+	}
+	if werr := r.write(scripting.Diagnostics(err)); werr != nil {
+		return werr
+	}
+	return errReported
+}
+
+// write は診断を書き出す（SARIFは診断がなくても空の結果を書き出す）
+func (r *reporter) write(diagnostics []scripting.Diagnostic) error {
+	for i := range diagnostics {
+		if diagnostics[i].Engine == "" {
+			diagnostics[i].Engine = r.engine
+		}
+	}
+
+	switch r.format {
+	case diagnosticsJSONL:
+		enc := json.NewEncoder(r.w)
+		enc.SetEscapeHTML(false)
+		for _, d := range diagnostics {
+			if err := enc.Encode(r.jsonDiagnostic(d)); err != nil {
+				return fmt.Errorf("診断の書き出しエラー: %w", err)
+			}
+		}
+		return nil
+	case diagnosticsSARIF:
+		enc := json.NewEncoder(r.w)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(r.sarifLog(diagnostics)); err != nil {
+			return fmt.Errorf("診断の書き出しエラー: %w", err)
+		}
+		return nil
+	default:
+		for _, d := range diagnostics {
+			fmt.Fprintln(r.w, d.Format())
+		}
+		return nil
+	}
+}
+
+// file はスクリプト名を実行時に指定したパスに置き換える（CIが行を特定できるように）
+//
+// load()・import したモジュールのパスはモジュールのルートからのパスにする。
+func (r *reporter) file(name string) string {
+	switch {
+	case name == r.script:
+		return filepath.ToSlash(r.scriptPath)
+	case name != "" && r.moduleRoot != "" && !filepath.IsAbs(name):
+		return filepath.ToSlash(filepath.Join(r.moduleRoot, name))
+	}
+	return name
+}
+
+// uri はSARIFの artifactLocation.uri（絶対パスは file:// のURIにする）
+func (r *reporter) uri(name string) string {
+	file := r.file(name)
+	if filepath.IsAbs(file) {
+		return (&url.URL{Scheme: "file", Path: filepath.ToSlash(file)}).String()
+	}
+	return file
+}
+
+// JSON Lines の1行
+type jsonDiagnostic struct {
+	Engine   string         `json:"engine"`
+	Severity string         `json:"severity"`
+	Code     string         `json:"code,omitempty"`
+	Message  string         `json:"message"`
+	File     string         `json:"file,omitempty"`
+	Range    *jsonRange     `json:"range,omitempty"`
+	Stack    []jsonLocation `json:"stack,omitempty"`
+	Notes    []jsonLocation `json:"notes,omitempty"`
+}
+
+type jsonRange struct {
+	Start jsonPosition `json:"start"`
+	End   jsonPosition `json:"end"`
+}
+
+type jsonPosition struct {
+	Line   int `json:"line"`
+	Column int `json:"column,omitempty"`
+}
+
+// スタックフレーム・補足の位置
+type jsonLocation struct {
+	Function string `json:"function,omitempty"`
+	Message  string `json:"message,omitempty"`
+	File     string `json:"file,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Column   int    `json:"column,omitempty"`
+}
+
+func (r *reporter) jsonDiagnostic(d scripting.Diagnostic) jsonDiagnostic {
+	jd := jsonDiagnostic{
+		Engine:   d.Engine,
+		Severity: d.Severity.String(),
+		Code:     d.Code,
+		Message:  d.Message,
+		File:     r.file(d.Pos.File),
+	}
+	if d.Pos.IsValid() {
+		start := jsonPosition{Line: d.Pos.Line, Column: d.Pos.Column}
+		end := start
+		if d.End.IsValid() {
+			end = jsonPosition{Line: d.End.Line, Column: d.End.Column}
+		}
+		jd.Range = &jsonRange{Start: start, End: end}
+	}
+	for _, f := range d.Stack {
+		jd.Stack = append(jd.Stack, jsonLocation{
+			Function: f.Function,
+			File:     r.file(f.Pos.File),
+			Line:     f.Pos.Line,
+			Column:   f.Pos.Column,
+		})
+	}
+	for _, n := range d.Notes {
+		jd.Notes = append(jd.Notes, jsonLocation{
+			Message: n.Message,
+			File:    r.file(n.Pos.File),
+			Line:    n.Pos.Line,
+			Column:  n.Pos.Column,
+		})
+	}
+	return jd
+}
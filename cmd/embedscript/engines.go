@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"path/filepath"
+	"strings"
+
+	"github.com/suinplayground/golang-embedded-scripting/scripting"
+	"github.com/suinplayground/golang-embedded-scripting/scripting/cuelang"
+	"github.com/suinplayground/golang-embedded-scripting/scripting/starlark"
+	"github.com/suinplayground/golang-embedded-scripting/scripting/typescript"
+)
+
+// --engine に指定できる名前とエンジン名の対応
+var engineAliases = map[string]string{
+	"ts":                  typescript.EngineName,
+	typescript.EngineName: typescript.EngineName,
+	"star":                starlark.EngineName,
+	starlark.EngineName:   starlark.EngineName,
+	cuelang.EngineName:    cuelang.EngineName,
+	"cuelang":             cuelang.EngineName,
+}
+
+// スクリプトの拡張子とエンジン名の対応（--engine 省略時に使う）
+var engineExtensions = map[string]string{
+	".ts":       typescript.EngineName,
+	".star":     starlark.EngineName,
+	".starlark": starlark.EngineName,
+	".cue":      cuelang.EngineName,
+}
+
+// パラメータ（@param）を宣言するヘッダーの行コメント（エンジン名 → 接頭辞）
+var engineLineComments = map[string]string{
+	typescript.EngineName: typescript.LineComment,
+	starlark.EngineName:   starlark.LineComment,
+	cuelang.EngineName:    cuelang.LineComment,
+}
+
+// engineName は --engine の値、または省略時はスクリプトの拡張子からエンジン名を決める
+func engineName(name, scriptPath string) (string, error) {
+	if name != "" {
+		resolved, ok := engineAliases[strings.ToLower(name)]
+		if !ok {
+			return "", fmt.Errorf("不明なエンジン: %s（ts, starlark, cue のいずれかを指定してください）", name)
+		}
+		return resolved, nil
+	}
+
+	ext := strings.ToLower(filepath.Ext(scriptPath))
+	resolved, ok := engineExtensions[ext]
+	if !ok {
+		return "", fmt.Errorf("拡張子 %q からエンジンを判定できません（--engine を指定してください）", ext)
+	}
+	return resolved, nil
+}
+
+// moduleConfig はスクリプトから読み込むモジュールの設定
+type moduleConfig struct {
+	// fsys はStarlarkの load()・TypeScriptの import・CUEのパッケージを読むファイルシステム（nilなら使えない）
+	fsys fs.FS
+	// packages はTypeScriptで import できる node_modules のパッケージ
+	packages []string
+	// offline はCUEの依存モジュールをレジストリから取得せず cue.mod/vendor から読む
+	offline bool
+	// cacheDir はStarlarkのバイトコードを保存するディレクトリ（空なら保存しない）
+	cacheDir string
+}
+
+// newEngine はエンジン名に対応するエンジンを作成する
+//
+// スクリプトのログは logs に送る（nilなら捨てる）。
+// cache があればコンパイル済みのプログラムをキャッシュする。
+// limits はStarlarkのトップレベルの実行にも使う。
+func newEngine(name string, logs slog.Handler, modules moduleConfig, cache *scripting.Cache, limits scripting.Limits) scripting.Engine {
+	switch name {
+	case typescript.EngineName:
+		return typescript.New(typescript.WithLogHandler(logs), typescript.WithModules(modules.fsys), typescript.WithPackages(modules.packages...), typescript.WithCache(cache))
+	case starlark.EngineName:
+		return starlark.New(starlark.WithLogHandler(logs), starlark.WithModules(modules.fsys), starlark.WithCache(cache), starlark.WithBytecodeCache(modules.cacheDir), starlark.WithCompileLimits(limits))
+	case cuelang.EngineName:
+		opts := []cuelang.Option{cuelang.WithLogHandler(logs), cuelang.WithModules(modules.fsys), cuelang.WithCache(cache)}
+		if modules.offline {
+			opts = append(opts, cuelang.WithOffline())
+		}
+		return cuelang.New(opts...)
+	default:
+		panic("unknown engine: " + name)
+	}
+}
+
+// newLogHandler はスクリプトのログをwにテキストで書き出すハンドラを作成する
+//
+// 結果と混ざらないよう、wには標準エラーを渡す。
+func newLogHandler(w io.Writer) slog.Handler {
+	return slog.NewTextHandler(w, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			// 時刻は出力しない
+			if len(groups) == 0 && a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})
+}
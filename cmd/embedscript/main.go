@@ -0,0 +1,69 @@
+// embedscript はTypeScript・Starlark・CUEのスクリプトでKubernetesマニフェストを変換するCLI
+//
+// 使い方:
+//
+//	embedscript run --engine ts|starlark|cue --script vpc-processor.ts --input subnets.yaml
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+)
+
+const usage = `使い方: embedscript <command> [flags]
+
+コマンド:
+  run          スクリプトで入力マニフェストを変換して標準出力に書き出す
+  describe     スクリプトが宣言しているパラメータを表示する
+  conformance  同じテストベクタを3つのエンジンで実行して期待値との差分を表示する
+  bench        各エンジンの起動時間・実行速度・メモリ使用量を計測して表にする
+
+各コマンドのフラグは "embedscript <command> -h" で確認できます。
+`
+
+func main() {
+	// Ctrl-Cで実行中のスクリプトを中断する
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		// 診断として出力済みのエラーは表示しない
+		if !errors.Is(err, errReported) {
+			fmt.Fprintf(os.Stderr, "エラー: %v\n", err)
+		}
+		stop()
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return fmt.Errorf("コマンドを指定してください")
+	}
+
+	switch args[0] {
+	case "run":
+		return runCommand(ctx, args[1:], stdin, stdout, stderr)
+	case "describe":
+		return describeCommand(args[1:], stdout, stderr)
+	case "conformance":
+		return conformanceCommand(ctx, args[1:], stdout, stderr)
+	case "bench":
+		return benchCommand(ctx, args[1:], stdout, stderr)
+	case "-h", "-help", "--help", "help":
+		fmt.Fprint(stdout, usage)
+		return nil
+	default:
+		fmt.Fprint(stderr, usage)
+		return fmt.Errorf("不明なコマンド: %s", args[0])
+	}
+}
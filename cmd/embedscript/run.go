@@ -0,0 +1,296 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/suinplayground/golang-embedded-scripting/kube"
+	"github.com/suinplayground/golang-embedded-scripting/scripting"
+	"github.com/suinplayground/golang-embedded-scripting/scripting/cuelang"
+)
+
+// paramsFileSuffix はスクリプトの隣に置くパラメータの宣言ファイルの接尾辞
+const paramsFileSuffix = ".params.yaml"
+
+// 繰り返し指定できる文字列フラグ
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ",") }
+
+func (l *stringList) Set(v string) error {
+	*l = append(*l, v)
+	return nil
+}
+
+// embedscript run
+func runCommand(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("run", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "使い方: embedscript run [--engine ts|starlark|cue] --script FILE [--input FILE]... [--output yaml|json] [--list] [--timeout 30s] [--max-* N] [--param KEY=VALUE]... [--params-file FILE] [--module-root DIR] [--allow-package NAME]... [--offline] [--cache-dir DIR] [--run-id ID] [--now RFC3339] [--diagnostics-format text|jsonl|sarif]")
+		fmt.Fprintln(stderr)
+		fs.PrintDefaults()
+	}
+
+	var (
+		engineFlag string
+		scriptPath string
+		inputs     stringList
+		output     string
+		asList     bool
+		timeout    time.Duration
+		limits     scripting.Limits
+		diagFormat string
+		diagOutput string
+		params     stringList
+		paramsFile string
+		moduleRoot string
+		packages   stringList
+		offline    bool
+		cacheDir   string
+		runID      string
+		nowFlag    string
+	)
+	fs.StringVar(&engineFlag, "engine", "", "スクリプトエンジン（ts, starlark, cue）。省略時は --script の拡張子から判定")
+	fs.StringVar(&scriptPath, "script", "", "実行するスクリプトファイル")
+	fs.Var(&inputs, "input", "入力マニフェスト（複数ドキュメントのYAML、JSON、kind: List）。複数指定可、\"-\" または省略時は標準入力")
+	fs.StringVar(&output, "output", string(kube.FormatYAML), "出力形式（yaml, json）")
+	fs.BoolVar(&asList, "list", false, "結果を v1/List にまとめて出力する")
+	fs.DurationVar(&timeout, "timeout", 0, "スクリプトのコンパイル・実行の制限時間（0で無制限）")
+	fs.Uint64Var(&limits.MaxSteps, "max-steps", 0, "Starlarkの実行ステップ数の上限（0で無制限）")
+	fs.IntVar(&limits.MaxCallStackSize, "max-call-stack", 0, "TypeScriptの関数呼び出しの深さの上限（0で無制限）")
+	fs.Uint64Var(&limits.MaxAllocBytes, "max-alloc-bytes", 0, "実行中のメモリ割り当てバイト数の上限（0で無制限）")
+	fs.IntVar(&limits.MaxObjects, "max-objects", 0, "出力オブジェクト数の上限（0で無制限）")
+	fs.IntVar(&limits.MaxDataBytes, "max-data-bytes", 0, "出力オブジェクト1つあたりのdataのバイト数の上限（0で無制限）")
+	fs.IntVar(&limits.MaxOutputBytes, "max-output-bytes", 0, "出力全体のバイト数の上限（0で無制限）")
+	fs.StringVar(&diagFormat, "diagnostics-format", diagnosticsText, "エラー・警告の出力形式（text, jsonl, sarif）")
+	fs.StringVar(&diagOutput, "diagnostics-output", "", "エラー・警告の出力先ファイル（省略時は標準エラー）")
+	fs.Var(&params, "param", "スクリプトに ctx.params として渡すパラメータ（KEY=VALUE、文字列）。複数指定可")
+	fs.StringVar(&paramsFile, "params-file", "", "パラメータの宣言ファイル（省略時はスクリプトと同じ名前の .params.yaml があれば使う）")
+	fs.StringVar(&moduleRoot, "module-root", "", "Starlarkの load()・TypeScriptの import・CUEのパッケージを読み込むモジュールのルートディレクトリ（省略時はスクリプトのディレクトリ、CUEは cue.mod のあるディレクトリ）")
+	fs.Var(&packages, "allow-package", "TypeScriptで --module-root の node_modules から import を許可するパッケージ。複数指定可")
+	fs.BoolVar(&offline, "offline", false, "CUEの依存モジュールをレジストリから取得せず、モジュールの cue.mod/vendor から読む")
+	fs.StringVar(&cacheDir, "cache-dir", "", "Starlarkのコンパイル済みのバイトコードを保存するディレクトリ（次の実行からパースを省く）")
+	fs.StringVar(&runID, "run-id", "", "ctx.runId に渡す実行ID（省略時はランダム）")
+	fs.StringVar(&nowFlag, "now", "", "ctx.now に渡す時刻（RFC3339、省略時は現在時刻）")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if scriptPath == "" {
+		fs.Usage()
+		return fmt.Errorf("--script を指定してください")
+	}
+
+	runOpts := []scripting.RunOption{scripting.WithLimits(limits), scripting.WithRunID(runID)}
+	if len(params) > 0 {
+		values, err := parseParams(params)
+		if err != nil {
+			return err
+		}
+		runOpts = append(runOpts, scripting.WithParams(values))
+	}
+	if nowFlag != "" {
+		now, err := time.Parse(time.RFC3339, nowFlag)
+		if err != nil {
+			return fmt.Errorf("--now はRFC3339で指定してください: %w", err)
+		}
+		runOpts = append(runOpts, scripting.WithNow(now))
+	}
+
+	format, err := kube.ParseFormat(output)
+	if err != nil {
+		return err
+	}
+
+	name, err := engineName(engineFlag, scriptPath)
+	if err != nil {
+		return err
+	}
+
+	script, err := readScript(scriptPath, paramsFile)
+	if err != nil {
+		return err
+	}
+
+	objects, err := readInputs(inputs, stdin)
+	if err != nil {
+		return err
+	}
+
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	diagWriter := stderr
+	if diagOutput != "" {
+		f, err := os.Create(diagOutput)
+		if err != nil {
+			return fmt.Errorf("診断の出力先の作成エラー: %w", err)
+		}
+		defer f.Close()
+		diagWriter = f
+	}
+	modules := moduleConfig{packages: packages, offline: offline, cacheDir: cacheDir}
+	switch {
+	case name == cuelang.EngineName:
+		// CUEは --module-root か cue.mod があるときだけパッケージとして読み込む
+		if moduleRoot == "" {
+			moduleRoot = findCUEModule(filepath.Dir(scriptPath))
+		}
+	case moduleRoot == "":
+		moduleRoot = filepath.Dir(scriptPath)
+	}
+	if moduleRoot != "" {
+		// スクリプトはモジュールのルートからのパスで読み込む
+		// （相対パスの load()・import はスクリプトのディレクトリから解決される）
+		rel, err := relPath(moduleRoot, scriptPath)
+		if err != nil || !filepath.IsLocal(rel) {
+			return fmt.Errorf("スクリプト %s がモジュールのルート %s の外にあります", scriptPath, moduleRoot)
+		}
+		script.Name = filepath.ToSlash(rel)
+		modules.fsys = os.DirFS(moduleRoot)
+	}
+	report, err := newReporter(diagFormat, diagWriter, name, script.Name, scriptPath, moduleRoot)
+	if err != nil {
+		return err
+	}
+
+	// スクリプトのログは標準エラーへ（標準出力は結果専用）
+	engine := newEngine(name, newLogHandler(stderr), modules, nil, limits)
+	program, err := engine.Compile(ctx, script)
+	if err != nil {
+		return report.fail(err)
+	}
+
+	result, err := program.Run(ctx, objects, runOpts...)
+	if err != nil {
+		return report.fail(err)
+	}
+
+	if err := report.write(result.Diagnostics); err != nil {
+		return err
+	}
+
+	if asList {
+		return kube.EncodeList(stdout, result.Objects, format)
+	}
+	return kube.Encode(stdout, result.Objects, format)
+}
+
+// parseParams は --param の KEY=VALUE をパラメータにする
+func parseParams(params []string) (map[string]interface{}, error) {
+	values := map[string]interface{}{}
+	for _, p := range params {
+		key, value, ok := strings.Cut(p, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("--param は KEY=VALUE で指定してください: %q", p)
+		}
+		values[key] = value
+	}
+	return values, nil
+}
+
+// findCUEModule は dir から親のディレクトリに向かって cue.mod のあるディレクトリを探す（なければ ""）
+//
+// 見つかったディレクトリはできるだけカレントディレクトリからの相対パスで返す。
+func findCUEModule(dir string) string {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+	for {
+		if info, err := os.Stat(filepath.Join(dir, "cue.mod")); err == nil && info.IsDir() {
+			if rel, err := relPath(".", dir); err == nil {
+				return rel
+			}
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// relPath は base からの target の相対パスを返す（どちらも絶対パスにしてから比べる）
+func relPath(base, target string) (string, error) {
+	base, err := filepath.Abs(base)
+	if err != nil {
+		return "", err
+	}
+	target, err = filepath.Abs(target)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Rel(base, target)
+}
+
+// readScript はスクリプトとパラメータの宣言ファイル（サイドカー）を読み込む
+//
+// paramsFile が空なら、スクリプトの拡張子を .params.yaml に替えたファイルがあれば使う。
+func readScript(scriptPath, paramsFile string) (scripting.Script, error) {
+	source, err := os.ReadFile(scriptPath)
+	if err != nil {
+		return scripting.Script{}, fmt.Errorf("スクリプトの読み込みエラー: %w", err)
+	}
+	script := scripting.Script{
+		Name:   filepath.Base(scriptPath),
+		Source: string(source),
+	}
+
+	if paramsFile == "" {
+		sidecar := strings.TrimSuffix(scriptPath, filepath.Ext(scriptPath)) + paramsFileSuffix
+		if _, err := os.Stat(sidecar); err != nil {
+			return script, nil
+		}
+		paramsFile = sidecar
+	}
+	data, err := os.ReadFile(paramsFile)
+	if err != nil {
+		return scripting.Script{}, fmt.Errorf("パラメータの宣言ファイルの読み込みエラー: %w", err)
+	}
+	script.Params, err = scripting.ParseParamsFile(filepath.Base(paramsFile), data)
+	if err != nil {
+		return scripting.Script{}, fmt.Errorf("パラメータの宣言ファイルの読み込みエラー: %w", err)
+	}
+	return script, nil
+}
+
+// readInputs は入力ファイル（"-" は標準入力）を読み込んでオブジェクトのリストにする
+func readInputs(paths []string, stdin io.Reader) ([]kube.Object, error) {
+	if len(paths) == 0 {
+		paths = []string{"-"}
+	}
+
+	var objects []kube.Object
+	for _, path := range paths {
+		decoded, err := readInput(path, stdin)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		objects = append(objects, decoded...)
+	}
+	return objects, nil
+}
+
+func readInput(path string, stdin io.Reader) ([]kube.Object, error) {
+	if path == "-" {
+		return kube.Decode(stdin)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("入力の読み込みエラー: %w", err)
+	}
+	defer f.Close()
+	return kube.Decode(f)
+}
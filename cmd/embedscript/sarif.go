@@ -0,0 +1,179 @@
+package main
+
+import (
+	"sort"
+
+	"github.com/suinplayground/golang-embedded-scripting/scripting"
+)
+
+// SARIF 2.1.0 の出力（CIでプルリクエストの行に注釈を付けるため）
+//
+// 仕様: https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html
+
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+	toolName     = "embedscript"
+	toolURI      = "https://github.com/suinplayground/golang-embedded-scripting"
+)
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool sarifTool `json:"tool"`
+	// 列はコードポイント単位で数える
+	ColumnKind string        `json:"columnKind"`
+	Results    []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules,omitempty"`
+}
+
+type sarifRule struct {
+	ID string `json:"id"`
+}
+
+type sarifResult struct {
+	RuleID           string            `json:"ruleId,omitempty"`
+	Level            string            `json:"level"`
+	Message          sarifMessage      `json:"message"`
+	Locations        []sarifLocation   `json:"locations,omitempty"`
+	RelatedLocations []sarifLocation   `json:"relatedLocations,omitempty"`
+	Stacks           []sarifStack      `json:"stacks,omitempty"`
+	Properties       map[string]string `json:"properties,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation *sarifPhysicalLocation `json:"physicalLocation,omitempty"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations,omitempty"`
+	Message          *sarifMessage          `json:"message,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+	EndLine     int `json:"endLine,omitempty"`
+	EndColumn   int `json:"endColumn,omitempty"`
+}
+
+type sarifLogicalLocation struct {
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+}
+
+type sarifStack struct {
+	Frames []sarifStackFrame `json:"frames"`
+}
+
+type sarifStackFrame struct {
+	Location sarifLocation `json:"location"`
+}
+
+// sarifLog は診断を1回分の実行（run）のSARIFログにする
+func (r *reporter) sarifLog(diagnostics []scripting.Diagnostic) sarifLog {
+	results := []sarifResult{}
+	codes := map[string]bool{}
+	for _, d := range diagnostics {
+		result := sarifResult{
+			RuleID:     d.Code,
+			Level:      sarifLevel(d.Severity),
+			Message:    sarifMessage{Text: d.Message},
+			Properties: map[string]string{"engine": d.Engine},
+		}
+		if loc := r.sarifPhysicalLocation(d.Pos, d.End); loc != nil {
+			result.Locations = []sarifLocation{{PhysicalLocation: loc}}
+		}
+		for _, n := range d.Notes {
+			result.RelatedLocations = append(result.RelatedLocations, sarifLocation{
+				PhysicalLocation: r.sarifPhysicalLocation(n.Pos, scripting.Position{}),
+				Message:          &sarifMessage{Text: n.Message},
+			})
+		}
+		if len(d.Stack) > 0 {
+			var stack sarifStack
+			for _, f := range d.Stack {
+				loc := sarifLocation{PhysicalLocation: r.sarifPhysicalLocation(f.Pos, scripting.Position{})}
+				if f.Function != "" {
+					loc.LogicalLocations = []sarifLogicalLocation{{Name: f.Function, Kind: "function"}}
+				}
+				stack.Frames = append(stack.Frames, sarifStackFrame{Location: loc})
+			}
+			result.Stacks = []sarifStack{stack}
+		}
+		if d.Code != "" {
+			codes[d.Code] = true
+		}
+		results = append(results, result)
+	}
+
+	var rules []sarifRule
+	for code := range codes {
+		rules = append(rules, sarifRule{ID: code})
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].ID < rules[j].ID })
+
+	return sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           toolName,
+				InformationURI: toolURI,
+				Rules:          rules,
+			}},
+			ColumnKind: "unicodeCodePoints",
+			Results:    results,
+		}},
+	}
+}
+
+// sarifPhysicalLocation はファイル内の位置（endが無効なら位置だけ）を返す（ファイルが不明ならnil）
+func (r *reporter) sarifPhysicalLocation(pos, end scripting.Position) *sarifPhysicalLocation {
+	if pos.File == "" {
+		return nil
+	}
+	loc := &sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: r.uri(pos.File)}}
+	if pos.IsValid() {
+		loc.Region = &sarifRegion{StartLine: pos.Line, StartColumn: pos.Column}
+		if end.IsValid() {
+			loc.Region.EndLine = end.Line
+			loc.Region.EndColumn = end.Column
+		}
+	}
+	return loc
+}
+
+func sarifLevel(s scripting.Severity) string {
+	switch s {
+	case scripting.SeverityError:
+		return "error"
+	case scripting.SeverityWarning:
+		return "warning"
+	default:
+		return "note"
+	}
+}
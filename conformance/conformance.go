@@ -0,0 +1,371 @@
+// Package conformance は同じ入力を複数のスクリプトエンジンで処理し、
+// 期待される出力とのフィールド単位の差分を報告する。
+//
+// テストベクタはディレクトリごとに1ケースで、次のファイルを置く：
+//
+//	<case>/input.yaml     入力マニフェスト
+//	<case>/expected.yaml  期待される出力
+package conformance
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/suinplayground/golang-embedded-scripting/kube"
+	"github.com/suinplayground/golang-embedded-scripting/scripting"
+)
+
+// 組み込みのテストベクタ
+//
+//go:embed testdata
+var defaultVectors embed.FS
+
+// DefaultVectors は組み込みのテストベクタを返す
+func DefaultVectors() fs.FS {
+	sub, err := fs.Sub(defaultVectors, "testdata")
+	if err != nil {
+		panic(err)
+	}
+	return sub
+}
+
+// Vector は1つのテストケース
+type Vector struct {
+	Name     string
+	Input    []kube.Object
+	Expected []kube.Object
+}
+
+// LoadVectors はfsys直下の各ディレクトリからテストベクタを読み込む
+func LoadVectors(fsys fs.FS) ([]Vector, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("テストベクタの読み込みエラー: %w", err)
+	}
+
+	var vectors []Vector
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		input, err := decodeFile(fsys, path.Join(entry.Name(), "input.yaml"))
+		if err != nil {
+			return nil, err
+		}
+		expected, err := decodeFile(fsys, path.Join(entry.Name(), "expected.yaml"))
+		if err != nil {
+			return nil, err
+		}
+
+		vectors = append(vectors, Vector{
+			Name:     entry.Name(),
+			Input:    input,
+			Expected: expected,
+		})
+	}
+
+	if len(vectors) == 0 {
+		return nil, fmt.Errorf("テストベクタが見つかりません")
+	}
+	return vectors, nil
+}
+
+func decodeFile(fsys fs.FS, name string) ([]kube.Object, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("テストベクタの読み込みエラー: %w", err)
+	}
+	defer f.Close()
+
+	objects, err := kube.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return objects, nil
+}
+
+// Target は検証対象のエンジンとスクリプトの組
+type Target struct {
+	Engine scripting.Engine
+	Script scripting.Script
+}
+
+// Report はすべてのテストケース・エンジンの結果
+type Report struct {
+	Cases []CaseResult
+}
+
+// Passed はすべてのケースが期待どおりだったかを返す
+func (r *Report) Passed() bool {
+	for _, c := range r.Cases {
+		for _, e := range c.Engines {
+			if !e.Passed() {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// CaseResult は1つのテストケースに対する各エンジンの結果
+type CaseResult struct {
+	Vector  string
+	Engines []EngineResult
+}
+
+// EngineResult は1つのエンジンの結果
+type EngineResult struct {
+	Engine string
+	// Err はコンパイル・実行時のエラー
+	Err   error
+	Diffs []Diff
+}
+
+// Passed はエラーも差分もなかったかを返す
+func (r EngineResult) Passed() bool {
+	return r.Err == nil && len(r.Diffs) == 0
+}
+
+// Run はすべてのテストベクタを各ターゲットで実行して期待値と比較する
+//
+// ctxが終了すると実行中のスクリプトは中断され、そのケースはエラーになる。
+func Run(ctx context.Context, vectors []Vector, targets []Target) *Report {
+	// スクリプトのコンパイルは1回だけ行う
+	programs := make([]scripting.Program, len(targets))
+	compileErrs := make([]error, len(targets))
+	for i, t := range targets {
+		programs[i], compileErrs[i] = t.Engine.Compile(ctx, t.Script)
+	}
+
+	report := &Report{}
+	for _, v := range vectors {
+		c := CaseResult{Vector: v.Name}
+		for i, t := range targets {
+			r := EngineResult{Engine: t.Engine.Name()}
+			if compileErrs[i] != nil {
+				r.Err = compileErrs[i]
+			} else if result, err := programs[i].Run(ctx, v.Input); err != nil {
+				r.Err = err
+			} else {
+				r.Diffs, r.Err = Compare(v.Expected, result.Objects)
+			}
+			c.Engines = append(c.Engines, r)
+		}
+		report.Cases = append(report.Cases, c)
+	}
+	return report
+}
+
+// DiffKind は差分の種類
+type DiffKind int
+
+const (
+	// Changed は値が異なる
+	Changed DiffKind = iota
+	// Missing は期待値にあるが出力にない
+	Missing
+	// Unexpected は出力にあるが期待値にない
+	Unexpected
+)
+
+// Diff はフィールド単位の差分
+type Diff struct {
+	// Object は差分のあるオブジェクト（"Kind namespace/name"）
+	Object string
+	// Path はオブジェクト内のフィールドのパス（オブジェクト自体の過不足では空）
+	Path     string
+	Kind     DiffKind
+	Expected interface{}
+	Actual   interface{}
+}
+
+func (d Diff) String() string {
+	target := d.Object
+	if d.Path != "" {
+		target += " " + d.Path
+	}
+	switch d.Kind {
+	case Missing:
+		return fmt.Sprintf("%s: 出力にありません（期待値: %s）", target, formatValue(d.Expected))
+	case Unexpected:
+		return fmt.Sprintf("%s: 期待値にありません（出力: %s）", target, formatValue(d.Actual))
+	default:
+		return fmt.Sprintf("%s: 期待値 %s, 出力 %s", target, formatValue(d.Expected), formatValue(d.Actual))
+	}
+}
+
+// Compare は期待値と出力をオブジェクトごと（kind と namespace/name で対応付け）に比較する
+//
+// オブジェクトの並び順は比較しない。
+func Compare(expected, actual []kube.Object) ([]Diff, error) {
+	expectedObjs, err := indexByName(expected)
+	if err != nil {
+		return nil, fmt.Errorf("期待値: %w", err)
+	}
+	actualObjs, err := indexByName(actual)
+	if err != nil {
+		return nil, fmt.Errorf("出力: %w", err)
+	}
+
+	// namespaceだけが異なるオブジェクトは同じものとして対応付け、フィールドの差分にする
+	pairByName(expectedObjs, actualObjs)
+
+	var diffs []Diff
+	for _, key := range unionKeys(expectedObjs, actualObjs) {
+		e, inExpected := expectedObjs[key]
+		a, inActual := actualObjs[key]
+		switch {
+		case !inActual:
+			diffs = append(diffs, Diff{Object: key, Kind: Missing, Expected: e})
+		case !inExpected:
+			diffs = append(diffs, Diff{Object: key, Kind: Unexpected, Actual: a})
+		default:
+			diffs = append(diffs, diffValues(key, "", e, a)...)
+		}
+	}
+	return diffs, nil
+}
+
+// pairByName は片方にしかないオブジェクトのうち、kind と name が一致するものを
+// 期待値側のキーに揃える
+func pairByName(expected, actual map[string]interface{}) {
+	for _, ekey := range unionKeys(expected, nil) {
+		if _, ok := actual[ekey]; ok {
+			continue
+		}
+		for _, akey := range unionKeys(actual, nil) {
+			if _, ok := expected[akey]; ok {
+				continue
+			}
+			if withoutNamespace(akey) == withoutNamespace(ekey) {
+				actual[ekey] = actual[akey]
+				delete(actual, akey)
+				break
+			}
+		}
+	}
+}
+
+// indexByName はオブジェクトを "Kind namespace/name" をキーとする汎用値にする
+func indexByName(objects []kube.Object) (map[string]interface{}, error) {
+	generic := kube.Generic(objects)
+
+	objs := make(map[string]interface{}, len(objects))
+	for i, obj := range objects {
+		key := obj.Kind() + " " + obj.Namespace() + "/" + obj.Name()
+		if _, dup := objs[key]; dup {
+			return nil, fmt.Errorf("%s が重複しています", key)
+		}
+		objs[key] = generic[i]
+	}
+	return objs, nil
+}
+
+// "Kind namespace/name" から namespace を除いた "Kind name" を返す
+func withoutNamespace(key string) string {
+	kindAndNamespace, name, _ := strings.Cut(key, "/")
+	kind, _, _ := strings.Cut(kindAndNamespace, " ")
+	return kind + " " + name
+}
+
+func diffValues(object, path string, expected, actual interface{}) []Diff {
+	em, eIsMap := expected.(map[string]interface{})
+	am, aIsMap := actual.(map[string]interface{})
+	if eIsMap && aIsMap {
+		var diffs []Diff
+		for _, k := range unionKeys(em, am) {
+			e, inExpected := em[k]
+			a, inActual := am[k]
+			p := joinPath(path, k)
+			switch {
+			case !inActual:
+				diffs = append(diffs, Diff{Object: object, Path: p, Kind: Missing, Expected: e})
+			case !inExpected:
+				diffs = append(diffs, Diff{Object: object, Path: p, Kind: Unexpected, Actual: a})
+			default:
+				diffs = append(diffs, diffValues(object, p, e, a)...)
+			}
+		}
+		return diffs
+	}
+
+	el, eIsList := expected.([]interface{})
+	al, aIsList := actual.([]interface{})
+	if eIsList && aIsList && len(el) == len(al) {
+		var diffs []Diff
+		for i := range el {
+			diffs = append(diffs, diffValues(object, fmt.Sprintf("%s[%d]", path, i), el[i], al[i])...)
+		}
+		return diffs
+	}
+
+	if isEmpty(expected) && isEmpty(actual) {
+		return nil
+	}
+	if !reflect.DeepEqual(expected, actual) {
+		return []Diff{{Object: object, Path: path, Kind: Changed, Expected: expected, Actual: actual}}
+	}
+	return nil
+}
+
+// nil と空のマップ・リストは同じとみなす（Kubernetes上の意味が同じため）
+func isEmpty(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case map[string]interface{}:
+		return len(v) == 0
+	case []interface{}:
+		return len(v) == 0
+	default:
+		return false
+	}
+}
+
+// キーに "." などを含む場合は ["..."] で表す
+func joinPath(path, key string) string {
+	for _, r := range key {
+		if !(r == '_' || r == '-' || 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9') {
+			return fmt.Sprintf("%s[%q]", path, key)
+		}
+	}
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func unionKeys(a, b map[string]interface{}) []string {
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func formatValue(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return fmt.Sprintf("%q", v)
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprintf("%v", v)
+		}
+		return string(b)
+	}
+}
@@ -0,0 +1,103 @@
+package conformance_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/suinplayground/golang-embedded-scripting/conformance"
+	"github.com/suinplayground/golang-embedded-scripting/examples"
+	"github.com/suinplayground/golang-embedded-scripting/kube"
+	"github.com/suinplayground/golang-embedded-scripting/scripting"
+	"github.com/suinplayground/golang-embedded-scripting/scripting/cuelang"
+	"github.com/suinplayground/golang-embedded-scripting/scripting/starlark"
+	"github.com/suinplayground/golang-embedded-scripting/scripting/typescript"
+)
+
+// TestDefaultVectors は組み込みのテストベクタで3つのエンジンの出力が期待値と一致するかを確認する
+func TestDefaultVectors(t *testing.T) {
+	vectors, err := conformance.LoadVectors(conformance.DefaultVectors())
+	if err != nil {
+		t.Fatalf("LoadVectors: %v", err)
+	}
+	if len(vectors) == 0 {
+		t.Fatal("テストベクタがありません")
+	}
+
+	var targets []conformance.Target
+	for _, engine := range []scripting.Engine{typescript.New(), starlark.New(), cuelang.New()} {
+		script, err := examples.VPCProcessor(engine.Name())
+		if err != nil {
+			t.Fatalf("VPCProcessor(%s): %v", engine.Name(), err)
+		}
+		targets = append(targets, conformance.Target{Engine: engine, Script: script})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	report := conformance.Run(ctx, vectors, targets)
+	for _, c := range report.Cases {
+		for _, e := range c.Engines {
+			t.Run(c.Vector+"/"+e.Engine, func(t *testing.T) {
+				if e.Err != nil {
+					t.Fatalf("エラー: %v", e.Err)
+				}
+				for _, d := range e.Diffs {
+					t.Error(d)
+				}
+			})
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	cm := func(name string, data map[string]interface{}) kube.Object {
+		return kube.Object{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata":   map[string]interface{}{"name": name},
+			"data":       data,
+		}
+	}
+	tests := []struct {
+		name     string
+		expected []kube.Object
+		actual   []kube.Object
+		diffs    int
+	}{
+		{
+			name:     "一致（順序は問わない）",
+			expected: []kube.Object{cm("a", nil), cm("b", nil)},
+			actual:   []kube.Object{cm("b", nil), cm("a", nil)},
+		},
+		{
+			name:     "値の違い",
+			expected: []kube.Object{cm("a", map[string]interface{}{"k": "1"})},
+			actual:   []kube.Object{cm("a", map[string]interface{}{"k": "2"})},
+			diffs:    1,
+		},
+		{
+			name:     "足りないオブジェクト",
+			expected: []kube.Object{cm("a", nil), cm("b", nil)},
+			actual:   []kube.Object{cm("a", nil)},
+			diffs:    1,
+		},
+		{
+			name:     "余分なオブジェクト",
+			expected: []kube.Object{cm("a", nil)},
+			actual:   []kube.Object{cm("a", nil), cm("c", nil)},
+			diffs:    1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diffs, err := conformance.Compare(tt.expected, tt.actual)
+			if err != nil {
+				t.Fatalf("Compare: %v", err)
+			}
+			if len(diffs) != tt.diffs {
+				t.Fatalf("差分が %d 件です（want %d）: %v", len(diffs), tt.diffs, diffs)
+			}
+		})
+	}
+}
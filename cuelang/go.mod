@@ -1,15 +0,0 @@
-module github.com/suinplayground/golang-embedded-scripting/cuelang
-
-go 1.23
-
-require cuelang.org/go v0.11.1
-
-require (
-	github.com/cockroachdb/apd/v3 v3.2.1 // indirect
-	github.com/google/uuid v1.6.0 // indirect
-	github.com/pelletier/go-toml/v2 v2.2.3 // indirect
-	golang.org/x/mod v0.21.0 // indirect
-	golang.org/x/net v0.30.0 // indirect
-	golang.org/x/text v0.19.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
-)
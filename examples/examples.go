@@ -0,0 +1,35 @@
+// Package examples はサンプルのスクリプトと入力マニフェストを埋め込む
+//
+// CLIの conformance / bench コマンドがデフォルトで使う。
+package examples
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+
+	"github.com/suinplayground/golang-embedded-scripting/scripting"
+)
+
+//go:embed vpc-processor.ts vpc-processor.star vpc-processor.cue subnets.yaml
+var FS embed.FS
+
+// VPC別グループ化スクリプトのファイル名（エンジン名 → ファイル名）
+var vpcProcessors = map[string]string{
+	"typescript": "vpc-processor.ts",
+	"starlark":   "vpc-processor.star",
+	"cue":        "vpc-processor.cue",
+}
+
+// VPCProcessor は指定したエンジン用のVPC別グループ化スクリプトを返す
+func VPCProcessor(engine string) (scripting.Script, error) {
+	name, ok := vpcProcessors[engine]
+	if !ok {
+		return scripting.Script{}, fmt.Errorf("エンジン %s のサンプルスクリプトはありません", engine)
+	}
+	source, err := fs.ReadFile(FS, name)
+	if err != nil {
+		return scripting.Script{}, err
+	}
+	return scripting.Script{Name: name, Source: string(source)}, nil
+}
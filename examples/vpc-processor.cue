@@ -0,0 +1,141 @@
+// @param groupLabel string = "vpc-id" グループ化に使うラベル
+// @param dataKey string = "subnet-id" 抽出してマージするdataのキー
+// @param mergedLabel string = "merged" マージ済みのConfigMapに付けるラベル（値は "true"）
+
+package process
+
+import "list"
+
+// 入力オブジェクト（kindは問わない）
+#Object: {
+	apiVersion: string
+	kind:       string
+	metadata: {
+		name:       string
+		namespace?: string
+		labels?: [string]: string
+		...
+	}
+	...
+}
+
+// 入力ConfigMap
+#ConfigMap: #Object & {
+	kind: "ConfigMap"
+	data?: [string]: string
+}
+
+// Goから渡される実行のコンテキスト（TypeScript・Starlarkの ctx と同じ形、log はなし）
+#Context: {
+	// ヘッダーの @param で宣言したパラメータ
+	params: {
+		groupLabel:  string
+		dataKey:     string
+		mergedLabel: string
+	}
+	runId:      string
+	scriptName: string
+	now:        string // RFC 3339
+}
+
+// 入力データ（@embedscript でエンジンにフィールドの役割を宣言する）
+inputConfigMaps: [...#Object] @embedscript(input)
+ctx:             #Context     @embedscript(context)
+
+_groupLabel:  ctx.params.groupLabel
+_dataKey:     ctx.params.dataKey
+_mergedLabel: ctx.params.mergedLabel
+
+// 処理対象のConfigMap（ConfigMap以外のkindは無視する）
+_configMaps: [for obj in inputConfigMaps if obj.kind == "ConfigMap" {obj & #ConfigMap}]
+
+// VPC IDごとにグループ化（vpc-idラベルがないものは除外、デバッグログに出す）
+vpcGroups: {
+	for cm in _configMaps
+	let vid = cm.metadata.labels[_groupLabel]
+	if vid != _|_
+	if vid != "" {
+		"\(vid)": vpcId: vid
+	}
+} @embedscript(debug)
+
+// ConfigMapをグループに追加
+enrichedGroups: {
+	for vid, group in vpcGroups {
+		"\(vid)": {
+			vpcId: group.vpcId
+			configMaps: [
+				for cm in _configMaps
+				if cm.metadata.labels[_groupLabel] != _|_
+				if cm.metadata.labels[_groupLabel] == vid {cm},
+			]
+
+			// namespaceはグループ内で最初に指定されたもの（なければ "default"）
+			let namespaces = [
+				for cm in configMaps
+				if cm.metadata.namespace != _|_
+				if cm.metadata.namespace != "" {cm.metadata.namespace},
+			]
+			namespace: *"default" | string
+			if len(namespaces) > 0 {
+				namespace: namespaces[0]
+			}
+
+			// ConfigMap名ごとのdataKey（subnet-id）の値（同じ名前が複数あれば入力順に並ぶ）
+			_subnetIds: {
+				for i, cm in configMaps
+				if cm.data[_dataKey] != _|_ {
+					"\(cm.metadata.name)": "\(i)": cm.data[_dataKey]
+				}
+			}
+		}
+	}
+}
+
+// マージ処理（出力）
+mergedConfigMaps: [
+	for vid, group in enrichedGroups {
+		{
+			apiVersion: "v1"
+			kind:       "ConfigMap"
+			metadata: {
+				name:      vid
+				namespace: group.namespace
+				labels: {
+					"\(_groupLabel)":  vid
+					"\(_mergedLabel)": "true"
+				}
+			}
+			// dataKeyのみを抽出（同じ名前のConfigMapは後のものが優先）
+			data: {
+				for name, ids in group._subnetIds
+				let values = [for _, id in ids {id}] {
+					"\(name).\(_dataKey)": values[len(values)-1]
+				}
+			}
+		}
+	},
+] @embedscript(output)
+
+// ログ（エンジンが評価後に記録する。文字列か {level, message}）
+logs: list.Concat([
+	for vid, group in enrichedGroups {
+		list.Concat([
+			["📦 VPC ID: \(vid) - ConfigMap数: \(len(group.configMaps))"],
+			[
+				for cm in group.configMaps
+				if cm.data[_dataKey] != _|_ {
+					"  ✓ 追加: \(cm.metadata.name).\(_dataKey) = \(cm.data[_dataKey])"
+				},
+			],
+		])
+	},
+	[
+		for cm in _configMaps
+		let vid = *cm.metadata.labels[_groupLabel] | ""
+		if vid == "" {
+			{level: "warn", message: "⚠ \(_groupLabel)ラベルがありません: \(cm.metadata.name)"}
+		},
+	],
+	["\n✅ 合計 \(len(mergedConfigMaps)) 個のVPCグループを作成"],
+]) @embedscript(logs)
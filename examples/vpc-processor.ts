@@ -0,0 +1,130 @@
+// @param groupLabel string = "vpc-id" グループ化に使うラベル
+// @param dataKey string = "subnet-id" 抽出してマージするdataのキー
+// @param mergedLabel string = "merged" マージ済みのConfigMapに付けるラベル（値は "true"）
+
+// Kubernetesオブジェクトの型定義（kindは問わない）
+interface KubeObject {
+  apiVersion: string;
+  kind: string;
+  metadata: Metadata;
+  [field: string]: unknown;
+}
+
+// Kubernetes ConfigMapの型定義
+interface ConfigMap extends KubeObject {
+  kind: "ConfigMap";
+  data?: { [key: string]: string };
+}
+
+// Metadataの型定義
+interface Metadata {
+  name: string;
+  namespace?: string;
+  labels?: { [key: string]: string };
+}
+
+// ヘッダーの @param で宣言したパラメータ
+interface Params {
+  groupLabel: string;
+  dataKey: string;
+  mergedLabel: string;
+}
+
+// Goから渡される実行のコンテキスト
+interface Context {
+  params: Params;
+  log: {
+    debug(...args: unknown[]): void;
+    info(...args: unknown[]): void;
+    warn(...args: unknown[]): void;
+    error(...args: unknown[]): void;
+  };
+  runId: string;
+  scriptName: string;
+  now: string; // RFC 3339
+}
+
+function isConfigMap(obj: KubeObject): obj is ConfigMap {
+  return obj.kind === "ConfigMap";
+}
+
+// VPC別にConfigMapをグループ化してマージする関数
+function groupByVpcAndMerge(objects: KubeObject[], params: Params): ConfigMap[] {
+  const { groupLabel, dataKey, mergedLabel } = params;
+
+  // VPC IDでグループ化
+  const vpcGroups = new Map<string, ConfigMap[]>();
+
+  for (const configMap of objects) {
+    // ConfigMap以外のkindは無視する
+    if (!isConfigMap(configMap)) {
+      continue;
+    }
+
+    const vpcId = configMap.metadata.labels?.[groupLabel];
+
+    if (!vpcId) {
+      console.log("⚠ " + groupLabel + "ラベルがありません:", configMap.metadata.name);
+      continue;
+    }
+
+    if (!vpcGroups.has(vpcId)) {
+      vpcGroups.set(vpcId, []);
+    }
+    vpcGroups.get(vpcId)!.push(configMap);
+  }
+
+  // グループごとにマージ
+  const mergedConfigMaps: ConfigMap[] = [];
+
+  for (const [vpcId, configMapsInVpc] of vpcGroups) {
+    console.log("📦 VPC ID:", vpcId, "- ConfigMap数:", configMapsInVpc.length);
+
+    // dataKey（subnet-id）のみを抽出してマージ
+    const mergedData: { [key: string]: string } = {};
+    let namespace: string | undefined;
+
+    for (const cm of configMapsInVpc) {
+      // namespaceを取得（最初のものを使用）
+      if (!namespace && cm.metadata.namespace) {
+        namespace = cm.metadata.namespace;
+      }
+
+      // dataKeyのみを抽出（同じ名前のConfigMapは後のものが優先）
+      for (const [key, value] of Object.entries(cm.data ?? {})) {
+        if (key === dataKey) {
+          // 元のConfigMap名をキー名として使用
+          const newKey = cm.metadata.name + "." + key;
+          mergedData[newKey] = value;
+          console.log("  ✓ 追加:", newKey, "=", value);
+        }
+      }
+    }
+
+    // マージ済みConfigMapを作成
+    const mergedConfigMap: ConfigMap = {
+      apiVersion: "v1",
+      kind: "ConfigMap",
+      metadata: {
+        name: vpcId,
+        namespace: namespace ?? "default",
+        labels: {
+          [groupLabel]: vpcId,
+          [mergedLabel]: "true"
+        }
+      },
+      data: mergedData
+    };
+
+    mergedConfigMaps.push(mergedConfigMap);
+  }
+
+  console.log("\n✅ 合計", mergedConfigMaps.length, "個のVPCグループを作成");
+
+  return mergedConfigMaps;
+}
+
+// エントリポイント：入力（ConfigMap以外のkindも含む）を受け取って結果を返す
+export default function transform(items: KubeObject[], ctx: Context): ConfigMap[] {
+  return groupByVpcAndMerge(items, ctx.params);
+}
@@ -0,0 +1,32 @@
+module github.com/suinplayground/golang-embedded-scripting
+
+go 1.23
+
+require (
+	cuelang.org/go v0.11.1
+	github.com/dop251/goja v0.0.0-20240927123429-241b342198c2
+	github.com/evanw/esbuild v0.25.10
+	github.com/go-sourcemap/sourcemap v2.1.4+incompatible
+	go.starlark.net v0.0.0-20250906160240-bf296ed553ea
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	cuelabs.dev/go/oci/ociregistry v0.0.0-20240906074133-82eb438dd565 // indirect
+	github.com/cockroachdb/apd/v3 v3.2.1 // indirect
+	github.com/dlclark/regexp2 v1.11.4 // indirect
+	github.com/emicklei/proto v1.13.2 // indirect
+	github.com/google/pprof v0.0.0-20230207041349-798e818bf904 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	github.com/mitchellh/go-wordwrap v1.0.1 // indirect
+	github.com/opencontainers/go-digest v1.0.0 // indirect
+	github.com/opencontainers/image-spec v1.1.0 // indirect
+	github.com/pelletier/go-toml/v2 v2.2.3 // indirect
+	github.com/protocolbuffers/txtpbfmt v0.0.0-20240823084532-8e6b51fa9bef // indirect
+	github.com/rogpeppe/go-internal v1.13.1 // indirect
+	golang.org/x/mod v0.21.0 // indirect
+	golang.org/x/net v0.30.0 // indirect
+	golang.org/x/oauth2 v0.23.0 // indirect
+	golang.org/x/sys v0.26.0 // indirect
+	golang.org/x/text v0.19.0 // indirect
+)
@@ -0,0 +1,80 @@
+// Package kube はスクリプトエンジン間で共有するKubernetesリソースの型を定義する。
+//
+// エンジンは任意のリソースをそのまま扱える Object（unstructured）を入出力とし、
+// ConfigMap・Secret などの型付きの構造体はその上のヘルパーとして提供する。
+package kube
+
+// Kubernetes ConfigMap構造体
+type ConfigMap struct {
+	APIVersion string            `json:"apiVersion"`
+	Kind       string            `json:"kind"`
+	Metadata   Metadata          `json:"metadata"`
+	Data       map[string]string `json:"data,omitempty"`
+	BinaryData map[string][]byte `json:"binaryData,omitempty"`
+	Immutable  *bool             `json:"immutable,omitempty"`
+}
+
+// Kubernetes Metadata
+type Metadata struct {
+	Name            string            `json:"name"`
+	GenerateName    string            `json:"generateName,omitempty"`
+	Namespace       string            `json:"namespace,omitempty"`
+	UID             string            `json:"uid,omitempty"`
+	ResourceVersion string            `json:"resourceVersion,omitempty"`
+	Labels          map[string]string `json:"labels,omitempty"`
+	Annotations     map[string]string `json:"annotations,omitempty"`
+	OwnerReferences []OwnerReference  `json:"ownerReferences,omitempty"`
+	Finalizers      []string          `json:"finalizers,omitempty"`
+}
+
+// Kubernetes OwnerReference
+type OwnerReference struct {
+	APIVersion         string `json:"apiVersion"`
+	Kind               string `json:"kind"`
+	Name               string `json:"name"`
+	UID                string `json:"uid"`
+	Controller         *bool  `json:"controller,omitempty"`
+	BlockOwnerDeletion *bool  `json:"blockOwnerDeletion,omitempty"`
+}
+
+// NewConfigMap は apiVersion/kind を設定したConfigMapを作成する
+func NewConfigMap(namespace, name string) *ConfigMap {
+	return &ConfigMap{
+		APIVersion: "v1",
+		Kind:       "ConfigMap",
+		Metadata:   Metadata{Name: name, Namespace: namespace},
+	}
+}
+
+// ToObject はConfigMapをObjectに変換する
+func (cm *ConfigMap) ToObject() (Object, error) {
+	return toObject(cm)
+}
+
+// ConfigMapFromObject はObjectをConfigMapに変換する
+//
+// ConfigMapの構造体にないフィールドは捨てられるので、
+// 変換後に書き戻す場合は元のObjectを直接編集すること。
+func ConfigMapFromObject(obj Object) (*ConfigMap, error) {
+	var cm ConfigMap
+	if err := fromObject(obj, "ConfigMap", &cm); err != nil {
+		return nil, err
+	}
+	return &cm, nil
+}
+
+// ConfigMaps はObjectのリストからConfigMapだけを取り出して変換する
+func ConfigMaps(objects []Object) ([]ConfigMap, error) {
+	var configMaps []ConfigMap
+	for _, obj := range objects {
+		if obj.Kind() != "ConfigMap" {
+			continue
+		}
+		cm, err := ConfigMapFromObject(obj)
+		if err != nil {
+			return nil, err
+		}
+		configMaps = append(configMaps, *cm)
+	}
+	return configMaps, nil
+}
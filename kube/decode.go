@@ -0,0 +1,175 @@
+package kube
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Decode はYAML/JSONのマニフェストを読み込んでオブジェクトのリストにする
+//
+// 以下の形式を受け付ける：
+//
+//   - "---" で区切られた複数ドキュメントのYAML
+//   - JSONオブジェクト・JSON配列（連結されたJSONストリームも可）
+//   - YAML/JSONのリスト
+//   - apiVersion: v1, kind: List の items
+//
+// kind は問わず、すべてのオブジェクトをそのまま返す。
+// YAMLのタイムスタンプ（creationTimestamp など）は書かれたままの文字列にする。
+func Decode(r io.Reader) ([]Object, error) {
+	docs, err := decodeDocuments(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []interface{}
+	for _, doc := range docs {
+		items = append(items, flattenItems(doc)...)
+	}
+	objects := make([]Object, len(items))
+	for i, item := range items {
+		n, err := normalize(item)
+		if err != nil {
+			return nil, fmt.Errorf("マニフェストの読み込みエラー（%d番目のオブジェクト）: %w", i+1, err)
+		}
+		m, ok := n.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("マニフェストの%d番目がオブジェクトではありません: %T", i+1, n)
+		}
+		objects[i] = Object(m)
+	}
+	return objects, nil
+}
+
+// decodeDocuments は入力を汎用値のドキュメント列として読み込む
+func decodeDocuments(r io.Reader) ([]interface{}, error) {
+	br := bufio.NewReader(r)
+
+	// 先頭が { か [ ならJSONストリームとして読む
+	if first, err := peekNonSpace(br); err == nil && (first == '{' || first == '[') {
+		return decodeJSONStream(br)
+	}
+	return decodeYAMLStream(br)
+}
+
+func decodeJSONStream(r io.Reader) ([]interface{}, error) {
+	dec := json.NewDecoder(r)
+	// 整数が float64 にならないよう json.Number で受ける
+	dec.UseNumber()
+	var docs []interface{}
+	for i := 1; ; i++ {
+		var doc interface{}
+		if err := dec.Decode(&doc); err != nil {
+			if errors.Is(err, io.EOF) {
+				return docs, nil
+			}
+			return nil, fmt.Errorf("JSONパースエラー（%d番目のドキュメント）: %w", i, err)
+		}
+		docs = append(docs, doc)
+	}
+}
+
+func decodeYAMLStream(r io.Reader) ([]interface{}, error) {
+	dec := yaml.NewDecoder(r)
+	var docs []interface{}
+	for i := 1; ; i++ {
+		var node yaml.Node
+		if err := dec.Decode(&node); err != nil {
+			if errors.Is(err, io.EOF) {
+				return docs, nil
+			}
+			return nil, fmt.Errorf("YAMLパースエラー（%d番目のドキュメント）: %w", i, err)
+		}
+		timestampsToStrings(&node)
+		var doc interface{}
+		if err := node.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("YAMLパースエラー（%d番目のドキュメント）: %w", i, err)
+		}
+		// 空のドキュメント（"---" の連続など）は読み飛ばす
+		if doc == nil {
+			continue
+		}
+		docs = append(docs, doc)
+	}
+}
+
+// timestampsToStrings はタグのないYAMLのタイムスタンプを文字列として読むようにする
+//
+// yaml.v3 は 2024-01-01T00:00:00Z を time.Time にするが、マニフェストでは文字列として扱う。
+func timestampsToStrings(node *yaml.Node) {
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!timestamp" {
+		node.Tag = "!!str"
+	}
+	for _, child := range node.Content {
+		timestampsToStrings(child)
+	}
+}
+
+// flattenItems はリストや kind: List を展開して個々のオブジェクトにする
+func flattenItems(doc interface{}) []interface{} {
+	switch v := doc.(type) {
+	case []interface{}:
+		var items []interface{}
+		for _, item := range v {
+			items = append(items, flattenItems(item)...)
+		}
+		return items
+	case map[string]interface{}:
+		if list, ok := listItems(v); ok {
+			return flattenItems(list)
+		}
+		return []interface{}{v}
+	case nil:
+		return nil
+	default:
+		return []interface{}{v}
+	}
+}
+
+// listItems は v1/List の items を返す（items がオブジェクトの配列でなければ false）
+//
+// ConfigMapList など他の *List は1つのオブジェクトとして扱う。
+func listItems(v map[string]interface{}) ([]interface{}, bool) {
+	if v["apiVersion"] != "v1" || v["kind"] != "List" {
+		return nil, false
+	}
+	items, ok := v["items"].([]interface{})
+	if !ok {
+		return nil, false
+	}
+	for _, item := range items {
+		if _, ok := item.(map[string]interface{}); !ok {
+			return nil, false
+		}
+	}
+	return items, true
+}
+
+// 先頭の空白を読み飛ばして最初の文字を返す（読み取り位置は進めない）
+func peekNonSpace(br *bufio.Reader) (byte, error) {
+	for n := 1; ; n++ {
+		buf, err := br.Peek(n)
+		if len(buf) < n {
+			return 0, err
+		}
+		switch c := buf[n-1]; c {
+		case ' ', '\t', '\r', '\n':
+			continue
+		default:
+			return c, nil
+		}
+	}
+}
+
+// unmarshalJSON は数値を json.Number で受けるjson.Unmarshal
+func unmarshalJSON(b []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
@@ -0,0 +1,109 @@
+package kube
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecode(t *testing.T) {
+	cm := func(name string) Object {
+		return Object{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata":   map[string]interface{}{"name": name},
+		}
+	}
+
+	tests := []struct {
+		name  string
+		input string
+		want  []Object
+	}{
+		{
+			name:  "複数ドキュメントのYAML",
+			input: "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: a\n---\n---\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: b\n",
+			want:  []Object{cm("a"), cm("b")},
+		},
+		{
+			name:  "JSONの配列",
+			input: `[{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"a"}},{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"b"}}]`,
+			want:  []Object{cm("a"), cm("b")},
+		},
+		{
+			name:  "連結されたJSON",
+			input: `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"a"}}` + "\n" + `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"b"}}`,
+			want:  []Object{cm("a"), cm("b")},
+		},
+		{
+			name:  "v1/List",
+			input: "apiVersion: v1\nkind: List\nitems:\n- apiVersion: v1\n  kind: ConfigMap\n  metadata:\n    name: a\n",
+			want:  []Object{cm("a")},
+		},
+		{
+			name:  "v1/List 以外の *List は展開しない",
+			input: "apiVersion: example.com/v1\nkind: WidgetList\nitems:\n- name: a\n",
+			want: []Object{{
+				"apiVersion": "example.com/v1",
+				"kind":       "WidgetList",
+				"items":      []interface{}{map[string]interface{}{"name": "a"}},
+			}},
+		},
+		{
+			name:  "items がオブジェクトの配列でない List は展開しない",
+			input: "apiVersion: v1\nkind: List\nitems:\n- a\n",
+			want:  []Object{{"apiVersion": "v1", "kind": "List", "items": []interface{}{"a"}}},
+		},
+		{
+			name:  "タイムスタンプは書かれたままの文字列",
+			input: "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: a\n  creationTimestamp: 2024-01-01T00:00:00Z\ndata:\n  date: 2024-01-01\n",
+			want: []Object{{
+				"apiVersion": "v1",
+				"kind":       "ConfigMap",
+				"metadata":   map[string]interface{}{"name": "a", "creationTimestamp": "2024-01-01T00:00:00Z"},
+				"data":       map[string]interface{}{"date": "2024-01-01"},
+			}},
+		},
+		{
+			name:  "数値",
+			input: `{"spec":{"replicas":3,"ratio":0.5}}`,
+			want:  []Object{{"spec": map[string]interface{}{"replicas": int64(3), "ratio": 0.5}}},
+		},
+		{
+			name:  "空の入力",
+			input: "",
+			want:  []Object{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Decode(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("Decode: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Decode() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "YAMLの構文", input: "a: [", want: "YAMLパースエラー"},
+		{name: "JSONの構文", input: "{\"a\": ", want: "JSONパースエラー"},
+		{name: "オブジェクト以外", input: "- a\n", want: "1番目がオブジェクトではありません"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Decode(strings.NewReader(tt.input))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
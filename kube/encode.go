@@ -0,0 +1,248 @@
+package kube
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Format はマニフェストの出力形式
+type Format string
+
+const (
+	// FormatYAML は "---" で区切られた複数ドキュメントのYAML
+	FormatYAML Format = "yaml"
+	// FormatJSON は改行で区切られたJSONオブジェクトのストリーム
+	FormatJSON Format = "json"
+)
+
+// ParseFormat は文字列から出力形式を決める
+func ParseFormat(s string) (Format, error) {
+	switch Format(s) {
+	case FormatYAML, "yml":
+		return FormatYAML, nil
+	case FormatJSON:
+		return FormatJSON, nil
+	default:
+		return "", fmt.Errorf("不明な出力形式: %s（yaml, json のいずれかを指定してください）", s)
+	}
+}
+
+// Encode はオブジェクトを指定した形式で書き出す
+//
+// 出力は kubectl apply -f - にそのまま渡せる。
+// キーはオブジェクトの apiVersion, kind, metadata と metadata の name, namespace を先頭に、
+// 残りをアルファベット順に並べるので差分が安定する（data や spec の中はアルファベット順のみ）。
+func Encode(w io.Writer, objects []Object, format Format) error {
+	return encodeDocuments(w, Generic(objects), format)
+}
+
+// EncodeList はオブジェクトを v1/List にまとめて1つのドキュメントとして書き出す
+func EncodeList(w io.Writer, objects []Object, format Format) error {
+	items := Generic(objects)
+	list := map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "List",
+		"metadata":   map[string]interface{}{},
+		"items":      items,
+	}
+	return encodeDocuments(w, []interface{}{list}, format)
+}
+
+func encodeDocuments(w io.Writer, docs []interface{}, format Format) error {
+	switch format {
+	case FormatYAML:
+		return encodeYAML(w, docs)
+	case FormatJSON:
+		return encodeJSON(w, docs)
+	default:
+		return fmt.Errorf("不明な出力形式: %s", format)
+	}
+}
+
+func encodeYAML(w io.Writer, docs []interface{}) error {
+	for i, doc := range docs {
+		if i > 0 {
+			if _, err := io.WriteString(w, "---\n"); err != nil {
+				return err
+			}
+		}
+
+		node, err := yamlNode(doc, objectOrder)
+		if err != nil {
+			return err
+		}
+
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(node); err != nil {
+			return fmt.Errorf("YAML変換エラー: %w", err)
+		}
+		if err := enc.Close(); err != nil {
+			return fmt.Errorf("YAML変換エラー: %w", err)
+		}
+	}
+	return nil
+}
+
+func encodeJSON(w io.Writer, docs []interface{}) error {
+	for _, doc := range docs {
+		var buf bytes.Buffer
+		if err := writeJSON(&buf, doc, objectOrder); err != nil {
+			return err
+		}
+
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, buf.Bytes(), "", "  "); err != nil {
+			return fmt.Errorf("JSON変換エラー: %w", err)
+		}
+		indented.WriteByte('\n')
+		if _, err := indented.WriteTo(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// keyOrder はマップのキーの並べ方
+type keyOrder int
+
+const (
+	// plainOrder はアルファベット順
+	plainOrder keyOrder = iota
+	// objectOrder はKubernetesのオブジェクト（トップレベルと v1/List の items）
+	objectOrder
+	// metadataOrder はオブジェクトの metadata
+	metadataOrder
+)
+
+// leadingKeys は先頭に並べるキー（Kubernetesのマニフェストで慣例的に先頭に来るもの）
+var leadingKeys = map[keyOrder][]string{
+	objectOrder:   {"apiVersion", "kind", "metadata"},
+	metadataOrder: {"name", "namespace"},
+}
+
+// sortedKeys は出力するキーの順序を決める
+func sortedKeys(m map[string]interface{}, order keyOrder) []string {
+	leading := leadingKeys[order]
+	keys := make([]string, 0, len(m))
+	for _, k := range leading {
+		if _, ok := m[k]; ok {
+			keys = append(keys, k)
+		}
+	}
+
+	rest := make([]string, 0, len(m))
+	for k := range m {
+		if !contains(leading, k) {
+			rest = append(rest, k)
+		}
+	}
+	sort.Strings(rest)
+
+	return append(keys, rest...)
+}
+
+// childOrder はマップ m のキー k の値の並べ方を返す
+func childOrder(m map[string]interface{}, order keyOrder, k string) keyOrder {
+	if order != objectOrder {
+		return plainOrder
+	}
+	switch {
+	case k == "metadata":
+		return metadataOrder
+	case k == "items" && m["apiVersion"] == "v1" && m["kind"] == "List":
+		return objectOrder
+	default:
+		return plainOrder
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// yamlNode はキー順序を固定したYAMLノードを作る
+//
+// order はマップのキーの並べ方（配列は要素に同じ並べ方を使う）。
+func yamlNode(v interface{}, order keyOrder) (*yaml.Node, error) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		for _, k := range sortedKeys(v, order) {
+			value, err := yamlNode(v[k], childOrder(v, order, k))
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content,
+				&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: k},
+				value,
+			)
+		}
+		return node, nil
+	case []interface{}:
+		node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for _, elem := range v {
+			value, err := yamlNode(elem, order)
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, value)
+		}
+		return node, nil
+	default:
+		// スカラーはyaml.v3に任せる（"true" などの文字列は自動でクォートされる）
+		node := &yaml.Node{}
+		if err := node.Encode(v); err != nil {
+			return nil, fmt.Errorf("YAML変換エラー: %w", err)
+		}
+		return node, nil
+	}
+}
+
+// writeJSON はキー順序を固定したJSONを書き出す
+func writeJSON(buf *bytes.Buffer, v interface{}, order keyOrder) error {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		buf.WriteByte('{')
+		for i, k := range sortedKeys(v, order) {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			key, _ := json.Marshal(k)
+			buf.Write(key)
+			buf.WriteByte(':')
+			if err := writeJSON(buf, v[k], childOrder(v, order, k)); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	case []interface{}:
+		buf.WriteByte('[')
+		for i, elem := range v {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeJSON(buf, elem, order); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("JSON変換エラー: %w", err)
+		}
+		buf.Write(b)
+	}
+	return nil
+}
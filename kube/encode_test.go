@@ -0,0 +1,173 @@
+package kube
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const manifest = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app
+  namespace: default
+  annotations:
+    note: "true"
+  creationTimestamp: "2024-01-01T00:00:00Z"
+data:
+  name: x
+  kind: y
+  apiVersion: z
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 3
+  template:
+    metadata:
+      name: pod
+      labels:
+        app: web
+`
+
+func TestEncodeRoundTrip(t *testing.T) {
+	want, err := Decode(strings.NewReader(manifest))
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	tests := []struct {
+		name   string
+		format Format
+		encode func(io.Writer, []Object, Format) error
+	}{
+		{name: "YAML", format: FormatYAML, encode: Encode},
+		{name: "JSON", format: FormatJSON, encode: Encode},
+		{name: "YAMLのList", format: FormatYAML, encode: EncodeList},
+		{name: "JSONのList", format: FormatJSON, encode: EncodeList},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := tt.encode(&buf, want, tt.format); err != nil {
+				t.Fatalf("Encode: %v", err)
+			}
+			got, err := Decode(&buf)
+			if err != nil {
+				t.Fatalf("Decode: %v\n%s", err, buf.String())
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("got %#v, want %#v", got, want)
+			}
+		})
+	}
+}
+
+func TestEncodeKeyOrder(t *testing.T) {
+	objects, err := Decode(strings.NewReader(manifest))
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	tests := []struct {
+		name   string
+		format Format
+		list   bool
+		want   string
+	}{
+		{
+			name:   "YAML",
+			format: FormatYAML,
+			want: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app
+  namespace: default
+  annotations:
+    note: "true"
+  creationTimestamp: "2024-01-01T00:00:00Z"
+data:
+  apiVersion: z
+  kind: "y"
+  name: x
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 3
+  template:
+    metadata:
+      labels:
+        app: web
+      name: pod
+`,
+		},
+		{
+			name:   "JSONのList",
+			format: FormatJSON,
+			list:   true,
+			want: `{
+  "apiVersion": "v1",
+  "kind": "List",
+  "metadata": {},
+  "items": [
+    {
+      "apiVersion": "v1",
+      "kind": "ConfigMap",
+      "metadata": {
+        "name": "app",
+        "namespace": "default",
+        "annotations": {
+          "note": "true"
+        },
+        "creationTimestamp": "2024-01-01T00:00:00Z"
+      },
+      "data": {
+        "apiVersion": "z",
+        "kind": "y",
+        "name": "x"
+      }
+    },
+    {
+      "apiVersion": "apps/v1",
+      "kind": "Deployment",
+      "metadata": {
+        "name": "web"
+      },
+      "spec": {
+        "replicas": 3,
+        "template": {
+          "metadata": {
+            "labels": {
+              "app": "web"
+            },
+            "name": "pod"
+          }
+        }
+      }
+    }
+  ]
+}
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			encode := Encode
+			if tt.list {
+				encode = EncodeList
+			}
+			if err := encode(&buf, objects, tt.format); err != nil {
+				t.Fatalf("Encode: %v", err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Fatalf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
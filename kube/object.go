@@ -0,0 +1,263 @@
+package kube
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Object は任意のKubernetesオブジェクト（unstructured）
+//
+// YAML/JSONの構造をそのまま map/slice で保持するので、annotations,
+// ownerReferences, binaryData や未知のフィールドも失われない。
+// 値は次の型に正規化される：
+//
+//	map[string]interface{}, []interface{}, string, bool, int64, float64, nil
+type Object map[string]interface{}
+
+// APIVersion は apiVersion を返す
+func (o Object) APIVersion() string { return o.stringField("apiVersion") }
+
+// Kind は kind を返す
+func (o Object) Kind() string { return o.stringField("kind") }
+
+// Name は metadata.name を返す
+func (o Object) Name() string { return stringValue(o.metadata()["name"]) }
+
+// Namespace は metadata.namespace を返す
+func (o Object) Namespace() string { return stringValue(o.metadata()["namespace"]) }
+
+// Labels は metadata.labels を返す（文字列以外の値は無視する）
+func (o Object) Labels() map[string]string { return stringMap(o.metadata()["labels"]) }
+
+// Annotations は metadata.annotations を返す（文字列以外の値は無視する）
+func (o Object) Annotations() map[string]string { return stringMap(o.metadata()["annotations"]) }
+
+func (o Object) stringField(key string) string { return stringValue(o[key]) }
+
+func (o Object) metadata() map[string]interface{} {
+	m, _ := o["metadata"].(map[string]interface{})
+	return m
+}
+
+// DeepCopy はObjectの完全なコピーを返す
+func (o Object) DeepCopy() Object {
+	if o == nil {
+		return nil
+	}
+	return Object(deepCopy(map[string]interface{}(o)).(map[string]interface{}))
+}
+
+// Generic はObjectのリストを各エンジンに渡す汎用値（[]interface{}）にする
+//
+// スクリプトが入力を書き換えても元のObjectに影響しないようディープコピーする。
+func Generic(objects []Object) []interface{} {
+	generic := make([]interface{}, len(objects))
+	for i, obj := range objects {
+		generic[i] = deepCopy(map[string]interface{}(obj))
+	}
+	return generic
+}
+
+// ObjectsFromGeneric はスクリプトエンジンが返した汎用値をObjectのリストにする
+func ObjectsFromGeneric(v interface{}) ([]Object, error) {
+	normalized, err := normalize(v)
+	if err != nil {
+		return nil, fmt.Errorf("結果の変換エラー: %w", err)
+	}
+
+	var items []interface{}
+	switch n := normalized.(type) {
+	case nil:
+		return nil, nil
+	case []interface{}:
+		items = n
+	default:
+		return nil, fmt.Errorf("結果がリストではありません: %T", normalized)
+	}
+
+	objects := make([]Object, len(items))
+	for i, item := range items {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("結果の%d番目がオブジェクトではありません: %T", i, item)
+		}
+		objects[i] = Object(m)
+	}
+	return objects, nil
+}
+
+// Normalize は任意のJSON互換の値をObjectの値の型に揃える
+//
+// 構造体など map/slice 以外の値はJSON経由で変換する。
+func Normalize(v interface{}) (interface{}, error) {
+	if n, err := normalize(v); err == nil {
+		return n, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("JSON変換エラー: %w", err)
+	}
+	var decoded interface{}
+	if err := unmarshalJSON(b, &decoded); err != nil {
+		return nil, fmt.Errorf("JSON デコードエラー: %w", err)
+	}
+	return normalize(decoded)
+}
+
+// normalize は各エンジン・デコーダが返す値をObjectの値の型に揃える
+//
+// time.Time（gojaの Date など）はRFC3339の文字列にする。
+func normalize(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case nil, string, bool, int64:
+		return v, nil
+	case Object:
+		return normalize(map[string]interface{}(v))
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, val := range v {
+			n, err := normalize(val)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", k, err)
+			}
+			m[k] = n
+		}
+		return m, nil
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, val := range v {
+			key, ok := k.(string)
+			if !ok {
+				return nil, fmt.Errorf("文字列以外のキーは使えません: %v", k)
+			}
+			n, err := normalize(val)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", key, err)
+			}
+			m[key] = n
+		}
+		return m, nil
+	case []interface{}:
+		l := make([]interface{}, len(v))
+		for i, val := range v {
+			n, err := normalize(val)
+			if err != nil {
+				return nil, fmt.Errorf("[%d]: %w", i, err)
+			}
+			l[i] = n
+		}
+		return l, nil
+	case []map[string]interface{}:
+		l := make([]interface{}, len(v))
+		for i, val := range v {
+			n, err := normalize(val)
+			if err != nil {
+				return nil, fmt.Errorf("[%d]: %w", i, err)
+			}
+			l[i] = n
+		}
+		return l, nil
+	case int:
+		return int64(v), nil
+	case int32:
+		return int64(v), nil
+	case uint64:
+		if v > math.MaxInt64 {
+			return float64(v), nil
+		}
+		return int64(v), nil
+	case float32:
+		return float64(v), nil
+	case float64:
+		return v, nil
+	case time.Time:
+		return v.Format(time.RFC3339), nil
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i, nil
+		}
+		f, err := v.Float64()
+		if err != nil {
+			return nil, err
+		}
+		return f, nil
+	default:
+		return nil, fmt.Errorf("サポートしていない値の型です: %T", v)
+	}
+}
+
+func deepCopy(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, val := range v {
+			m[k] = deepCopy(val)
+		}
+		return m
+	case []interface{}:
+		l := make([]interface{}, len(v))
+		for i, val := range v {
+			l[i] = deepCopy(val)
+		}
+		return l
+	default:
+		return v
+	}
+}
+
+func stringValue(v interface{}) string {
+	s, _ := v.(string)
+	return s
+}
+
+func stringMap(v interface{}) map[string]string {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	result := make(map[string]string, len(m))
+	for k, val := range m {
+		if s, ok := val.(string); ok {
+			result[k] = s
+		}
+	}
+	return result
+}
+
+// toObject は型付きのリソースをJSON経由でObjectにする
+func toObject(v interface{}) (Object, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("JSON変換エラー: %w", err)
+	}
+	return decodeObjectJSON(b)
+}
+
+func decodeObjectJSON(b []byte) (Object, error) {
+	var m map[string]interface{}
+	if err := unmarshalJSON(b, &m); err != nil {
+		return nil, fmt.Errorf("JSON デコードエラー: %w", err)
+	}
+	normalized, err := normalize(m)
+	if err != nil {
+		return nil, err
+	}
+	return Object(normalized.(map[string]interface{})), nil
+}
+
+// fromObject はObjectをJSON経由で型付きのリソースにする
+func fromObject(obj Object, kind string, out interface{}) error {
+	if obj.Kind() != kind {
+		return fmt.Errorf("%s ではありません: kind=%q", kind, obj.Kind())
+	}
+	b, err := json.Marshal(obj)
+	if err != nil {
+		return fmt.Errorf("JSON変換エラー: %w", err)
+	}
+	if err := json.Unmarshal(b, out); err != nil {
+		return fmt.Errorf("%s %s のデコードエラー: %w", kind, obj.Name(), err)
+	}
+	return nil
+}
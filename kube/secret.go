@@ -0,0 +1,37 @@
+package kube
+
+// Kubernetes Secret構造体
+//
+// Data はJSON/YAML上ではbase64文字列で、構造体ではデコード済みのバイト列になる。
+type Secret struct {
+	APIVersion string            `json:"apiVersion"`
+	Kind       string            `json:"kind"`
+	Metadata   Metadata          `json:"metadata"`
+	Type       string            `json:"type,omitempty"`
+	Data       map[string][]byte `json:"data,omitempty"`
+	StringData map[string]string `json:"stringData,omitempty"`
+	Immutable  *bool             `json:"immutable,omitempty"`
+}
+
+// NewSecret は apiVersion/kind を設定したSecretを作成する
+func NewSecret(namespace, name string) *Secret {
+	return &Secret{
+		APIVersion: "v1",
+		Kind:       "Secret",
+		Metadata:   Metadata{Name: name, Namespace: namespace},
+	}
+}
+
+// ToObject はSecretをObjectに変換する
+func (s *Secret) ToObject() (Object, error) {
+	return toObject(s)
+}
+
+// SecretFromObject はObjectをSecretに変換する
+func SecretFromObject(obj Object) (*Secret, error) {
+	var s Secret
+	if err := fromObject(obj, "Secret", &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
@@ -0,0 +1,192 @@
+package scripting
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"reflect"
+	"sync"
+)
+
+// Cache はコンパイル済みのプログラムのキャッシュ
+//
+// キーはスクリプトの内容のハッシュ（CacheKey）で、件数が上限を超えたら
+// 最も長く使われていないものから追い出す（LRU）。複数のgoroutine・
+// 複数のEngineから使える（コンパイル結果が変わる設定の違うEngineは別のキーになる）。
+// 各エンジンの WithCache に渡す。
+type Cache struct {
+	mu         sync.Mutex
+	maxEntries int
+	order      *list.List // 先頭が最近使ったもの
+	entries    map[string]*list.Element
+	stats      CacheStats
+}
+
+type cacheEntry struct {
+	key   string
+	value interface{}
+}
+
+// CacheStats はキャッシュの統計
+type CacheStats struct {
+	// Hits はキャッシュしたプログラムを使った回数
+	Hits uint64
+	// Misses はキャッシュになくコンパイルした回数（Stale を含む）
+	Misses uint64
+	// Stale は読み込んだモジュールが変わっていてキャッシュを捨てた回数
+	Stale uint64
+	// Evictions は上限を超えて追い出した件数
+	Evictions uint64
+	// Entries は今キャッシュしている件数
+	Entries int
+	// MaxEntries は件数の上限（0なら無制限）
+	MaxEntries int
+}
+
+// NewCache は最大 maxEntries 件のキャッシュを作成する（0以下なら無制限）
+func NewCache(maxEntries int) *Cache {
+	if maxEntries < 0 {
+		maxEntries = 0
+	}
+	return &Cache{
+		maxEntries: maxEntries,
+		order:      list.New(),
+		entries:    map[string]*list.Element{},
+	}
+}
+
+// CacheKey はエンジン名・スクリプト（名前・ソース・パラメータの宣言）と
+// エンジンの設定 config からキャッシュのキーを作る
+func CacheKey(engine string, script Script, config ...string) string {
+	h := sha256.New()
+	// 区切りを入れて、値の境界が違う組み合わせが同じキーにならないようにする
+	write := func(s string) {
+		fmt.Fprintf(h, "%d:%s\x00", len(s), s)
+	}
+	write(engine)
+	write(script.Name)
+	write(script.Source)
+	write(fmt.Sprint(script.Params != nil))
+	for _, p := range script.Params {
+		write(fmt.Sprintf("%s\x00%s\x00%#v\x00%s\x00%v", p.Name, p.Type, p.Default, p.Description, p.Pos))
+	}
+	for _, c := range config {
+		write(c)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// ModulesKey はモジュールのファイルシステム fsys を区別する CacheKey の設定の値を返す
+//
+// os.DirFS はディレクトリ、マップやポインタ（fstest.MapFS など）は同じ値かで区別する。
+// ファイルの内容の変化はキャッシュの確認（ModuleDigests.Unchanged など）で見る。
+func ModulesKey(fsys fs.FS) string {
+	if fsys == nil {
+		return ""
+	}
+	switch reflect.ValueOf(fsys).Kind() {
+	case reflect.Map, reflect.Pointer, reflect.Func, reflect.Chan, reflect.Slice, reflect.UnsafePointer:
+		return fmt.Sprintf("%T(%p)", fsys, fsys)
+	default:
+		return fmt.Sprintf("%T(%v)", fsys, fsys)
+	}
+}
+
+// Get はキーのプログラムを返す
+//
+// valid が false を返したもの（読み込んだモジュールが変わったなど）は捨ててミスにする。
+// valid はロックの外で呼ぶ。
+func (c *Cache) Get(key string, valid func(value interface{}) bool) (interface{}, bool) {
+	c.mu.Lock()
+	elem, ok := c.entries[key]
+	if !ok {
+		c.stats.Misses++
+		c.mu.Unlock()
+		return nil, false
+	}
+	value := elem.Value.(*cacheEntry).value
+	c.mu.Unlock()
+
+	if valid != nil && !valid(value) {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		// 確認している間に置き換えられていなければ捨てる
+		if cur, ok := c.entries[key]; ok && cur == elem {
+			c.order.Remove(elem)
+			delete(c.entries, key)
+		}
+		c.stats.Misses++
+		c.stats.Stale++
+		return nil, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// 追い出されていれば何もしない
+	c.order.MoveToFront(elem)
+	c.stats.Hits++
+	return value, true
+}
+
+// Put はキーのプログラムを追加し、上限を超えたら最も長く使われていないものを追い出す
+func (c *Cache) Put(key string, value interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[key]; ok {
+		elem.Value.(*cacheEntry).value = value
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&cacheEntry{key: key, value: value})
+	for c.maxEntries > 0 && c.order.Len() > c.maxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+		c.stats.Evictions++
+	}
+}
+
+// Stats はキャッシュの統計を返す
+func (c *Cache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s := c.stats
+	s.Entries = c.order.Len()
+	s.MaxEntries = c.maxEntries
+	return s
+}
+
+// ModuleDigests はスクリプトが読み込んだモジュールの内容のハッシュ（パス → sha256）
+//
+// キャッシュしたプログラムのモジュールが変わっていないかを確認するのに使う。
+type ModuleDigests map[string][sha256.Size]byte
+
+// NewModuleDigests はモジュールのソース（パス → ソース）のハッシュを作る（スクリプト自身は除く）
+func NewModuleDigests(script Script, sources map[string]string) ModuleDigests {
+	d := ModuleDigests{}
+	for name, source := range sources {
+		if name != script.Name {
+			d[name] = sha256.Sum256([]byte(source))
+		}
+	}
+	return d
+}
+
+// Unchanged はすべてのモジュールが fsys で同じ内容のままかを返す
+func (d ModuleDigests) Unchanged(fsys fs.FS) bool {
+	if len(d) == 0 {
+		return true
+	}
+	if fsys == nil {
+		return false
+	}
+	for name, sum := range d {
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil || sha256.Sum256(data) != sum {
+			return false
+		}
+	}
+	return true
+}
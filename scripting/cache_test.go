@@ -0,0 +1,158 @@
+package scripting
+
+import (
+	"io/fs"
+	"os"
+	"testing"
+	"testing/fstest"
+)
+
+func TestCacheGetPut(t *testing.T) {
+	valid := func(interface{}) bool { return true }
+	stale := func(interface{}) bool { return false }
+
+	tests := []struct {
+		name string
+		run  func(c *Cache)
+		want CacheStats
+	}{
+		{
+			name: "ミス",
+			run:  func(c *Cache) { c.Get("a", valid) },
+			want: CacheStats{Misses: 1},
+		},
+		{
+			name: "ヒット",
+			run: func(c *Cache) {
+				c.Put("a", 1)
+				c.Get("a", valid)
+				c.Get("a", nil)
+			},
+			want: CacheStats{Hits: 2, Entries: 1},
+		},
+		{
+			name: "古いものは捨てる",
+			run: func(c *Cache) {
+				c.Put("a", 1)
+				c.Get("a", stale)
+				c.Get("a", valid)
+			},
+			want: CacheStats{Misses: 2, Stale: 1},
+		},
+		{
+			name: "上限を超えたら最も長く使われていないものを追い出す",
+			run: func(c *Cache) {
+				c.Put("a", 1)
+				c.Put("b", 2)
+				c.Get("a", valid) // b が最も古くなる
+				c.Put("c", 3)
+				c.Get("b", valid)
+				c.Get("a", valid)
+			},
+			want: CacheStats{Hits: 2, Misses: 1, Evictions: 1, Entries: 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewCache(2)
+			tt.run(c)
+			tt.want.MaxEntries = 2
+			if got := c.Stats(); got != tt.want {
+				t.Fatalf("Stats() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCacheKey(t *testing.T) {
+	base := Script{Name: "a.star", Source: "x = 1"}
+	tests := []struct {
+		name   string
+		engine string
+		script Script
+		config []string
+	}{
+		{name: "エンジン", engine: "ts", script: base},
+		{name: "名前", engine: "starlark", script: Script{Name: "b.star", Source: base.Source}},
+		{name: "ソース", engine: "starlark", script: Script{Name: base.Name, Source: "x = 2"}},
+		{name: "パラメータの宣言", engine: "starlark", script: Script{Name: base.Name, Source: base.Source, Params: []Param{{Name: "p", Type: ParamString}}}},
+		{name: "設定", engine: "starlark", script: base, config: []string{"offline"}},
+		// 値の境界がずれても同じキーにならない
+		{name: "境界", engine: "starlar", script: Script{Name: "ka.star", Source: base.Source}},
+	}
+	want := CacheKey("starlark", base)
+	if got := CacheKey("starlark", base); got != want {
+		t.Fatalf("同じスクリプトのキーが違います: %s, %s", got, want)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CacheKey(tt.engine, tt.script, tt.config...); got == want {
+				t.Fatalf("キーが同じです: %s", got)
+			}
+		})
+	}
+}
+
+func TestModulesKey(t *testing.T) {
+	a := fstest.MapFS{"a.star": {Data: []byte("x = 1")}}
+	b := fstest.MapFS{"a.star": {Data: []byte("x = 1")}}
+	tests := []struct {
+		name string
+		x, y fs.FS
+		same bool
+	}{
+		{name: "同じマップ", x: a, y: a, same: true},
+		{name: "内容が同じ別のマップ", x: a, y: b},
+		{name: "同じディレクトリ", x: os.DirFS("testdata"), y: os.DirFS("testdata"), same: true},
+		{name: "別のディレクトリ", x: os.DirFS("a"), y: os.DirFS("b")},
+		{name: "なしとあり", x: nil, y: a},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if same := ModulesKey(tt.x) == ModulesKey(tt.y); same != tt.same {
+				t.Fatalf("同じキー = %v, want %v（%q, %q）", same, tt.same, ModulesKey(tt.x), ModulesKey(tt.y))
+			}
+		})
+	}
+}
+
+func TestModuleDigestsUnchanged(t *testing.T) {
+	script := Script{Name: "main.star", Source: "x = 1"}
+	digests := NewModuleDigests(script, map[string]string{
+		"main.star":  script.Source,
+		"lib/a.star": "a = 1",
+	})
+	tests := []struct {
+		name string
+		fsys fstest.MapFS
+		want bool
+	}{
+		{
+			name: "同じ",
+			fsys: fstest.MapFS{"lib/a.star": {Data: []byte("a = 1")}},
+			want: true,
+		},
+		{
+			name: "スクリプト自身は確認しない",
+			fsys: fstest.MapFS{"lib/a.star": {Data: []byte("a = 1")}, "main.star": {Data: []byte("x = 2")}},
+			want: true,
+		},
+		{
+			name: "変更",
+			fsys: fstest.MapFS{"lib/a.star": {Data: []byte("a = 2")}},
+			want: false,
+		},
+		{
+			name: "削除",
+			fsys: fstest.MapFS{},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := digests.Unchanged(tt.fsys); got != tt.want {
+				t.Fatalf("Unchanged() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
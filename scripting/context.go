@@ -0,0 +1,92 @@
+package scripting
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/suinplayground/golang-embedded-scripting/kube"
+)
+
+// Context はスクリプトに ctx として渡す実行のコンテキスト
+//
+// 3つのエンジンで同じ形で渡される（ctx.log はTypeScriptとStarlarkのみ。
+// CUEでは関数を呼べないので logs フィールドを使う）：
+//
+//	params     ユーザーのパラメータ
+//	log        ロガー（debug, info, warn, error）
+//	runId      実行ID
+//	scriptName スクリプト名
+//	now        時刻（RFC 3339。実行中は変わらず、WithNow で固定できる）
+type Context struct {
+	Params     map[string]interface{}
+	RunID      string
+	ScriptName string
+	Now        time.Time
+}
+
+// WithParams は ctx.params に渡すパラメータを設定する（値はJSONに変換できる型）
+func WithParams(params map[string]interface{}) RunOption {
+	return func(o *RunOptions) {
+		o.Params = params
+	}
+}
+
+// WithRunID は ctx.runId を設定する
+func WithRunID(id string) RunOption {
+	return func(o *RunOptions) {
+		o.RunID = id
+	}
+}
+
+// WithNow は ctx.now を設定する（テストなどで結果を決定的にするため）
+func WithNow(now time.Time) RunOption {
+	return func(o *RunOptions) {
+		o.Now = now
+	}
+}
+
+// Context は実行の設定からスクリプトに渡すContextを作る（エンジンの実装向け）
+//
+// RunIDとNowが未設定なら、ランダムなIDと現在時刻を使う。
+func (o RunOptions) Context(script string) Context {
+	c := Context{
+		Params:     o.Params,
+		RunID:      o.RunID,
+		ScriptName: script,
+		Now:        o.Now,
+	}
+	if c.RunID == "" {
+		c.RunID = newRunID()
+	}
+	if c.Now.IsZero() {
+		c.Now = time.Now()
+	}
+	return c
+}
+
+// Generic はContextをエンジンに渡す汎用値にする（log は各エンジンが追加する）
+func (c Context) Generic() (map[string]interface{}, error) {
+	params := map[string]interface{}{}
+	if c.Params != nil {
+		normalized, err := kube.Normalize(c.Params)
+		if err != nil {
+			return nil, fmt.Errorf("パラメータの変換エラー: %w", err)
+		}
+		params = normalized.(map[string]interface{})
+	}
+	return map[string]interface{}{
+		"params":     params,
+		"runId":      c.RunID,
+		"scriptName": c.ScriptName,
+		"now":        c.Now.UTC().Format(time.RFC3339Nano),
+	}, nil
+}
+
+// newRunID はランダムな実行IDを作る
+func newRunID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,160 @@
+package cuelang
+
+import (
+	"fmt"
+	"strings"
+
+	"cuelang.org/go/cue"
+
+	"github.com/suinplayground/golang-embedded-scripting/scripting"
+)
+
+// attrName はフィールドの役割を宣言する属性の名前（@embedscript(input) など）
+const attrName = "embedscript"
+
+// フィールドの役割（@embedscript(...) の引数）
+const (
+	roleInput  = "input"
+	roleOutput = "output"
+	roleLogs   = "logs"
+	roleDebug  = "debug"
+	roleCtx    = "context"
+)
+
+// Contract はCUEスクリプトの入出力のフィールドのパス
+//
+// スクリプトのトップレベルのフィールドに @embedscript(input|output|logs|debug|context)
+// の属性を付けて宣言するか、WithContract で指定する（空の項目は属性・デフォルトを使う）。
+type Contract struct {
+	// Input は入力のリストを設定するパス（デフォルトは inputConfigMaps）
+	Input string
+	// Output は結果のリストを読むパス（デフォルトは mergedConfigMaps）
+	Output string
+	// Logs はログのリストを読むパス（デフォルトは logs、なければログなし）
+	Logs string
+	// Debug は評価後にデバッグログとして値を記録するパス（存在するときだけ読む）
+	Debug []string
+	// Context は実行時のctx（scripting.Context）を設定するパス（デフォルトは ctx、なければ設定しない）
+	Context string
+}
+
+// DefaultContract は属性も WithContract もない場合の契約
+var DefaultContract = Contract{
+	Input:   "inputConfigMaps",
+	Output:  "mergedConfigMaps",
+	Logs:    "logs",
+	Context: "ctx",
+}
+
+// WithContract はスクリプトの属性より優先する入出力のパスを設定する
+func WithContract(c Contract) Option {
+	return func(e *Engine) {
+		e.contract = c
+	}
+}
+
+// resolveContract は WithContract の指定・スクリプトの属性・デフォルトの順に契約を決めて確認する
+//
+// 入力と出力のフィールドがスクリプトにない場合はエラーにする。
+func resolveContract(script scripting.Script, sources sourceFiles, value cue.Value, override Contract) (Contract, error) {
+	declared, err := declaredContract(script, sources, value)
+	if err != nil {
+		return Contract{}, err
+	}
+
+	c := DefaultContract
+	for _, src := range []Contract{declared, override} {
+		if src.Input != "" {
+			c.Input = src.Input
+		}
+		if src.Output != "" {
+			c.Output = src.Output
+		}
+		if src.Logs != "" {
+			c.Logs = src.Logs
+		}
+		if src.Debug != nil {
+			c.Debug = src.Debug
+		}
+		if src.Context != "" {
+			c.Context = src.Context
+		}
+	}
+
+	for _, f := range []struct{ role, path string }{{roleInput, c.Input}, {roleOutput, c.Output}} {
+		path := cue.ParsePath(f.path)
+		if path.Err() != nil {
+			return Contract{}, contractError(script, sources, fmt.Sprintf("%s のパス %q が正しくありません: %v", f.role, f.path, path.Err()), scripting.Position{})
+		}
+		if !value.LookupPath(path).Exists() {
+			return Contract{}, contractError(script, sources, fmt.Sprintf("%s のフィールド %s がありません（@%s(%s) でフィールドを指定してください）", f.role, f.path, attrName, f.role), scripting.Position{})
+		}
+	}
+	for _, p := range append([]string{c.Logs, c.Context}, c.Debug...) {
+		if path := cue.ParsePath(p); path.Err() != nil {
+			return Contract{}, contractError(script, sources, fmt.Sprintf("パス %q が正しくありません: %v", p, path.Err()), scripting.Position{})
+		}
+	}
+	return c, nil
+}
+
+// declaredContract はトップレベルのフィールドの @embedscript 属性から契約を読む
+func declaredContract(script scripting.Script, sources sourceFiles, value cue.Value) (Contract, error) {
+	var c Contract
+	iter, err := value.Fields(cue.Optional(true))
+	if err != nil {
+		return c, nil
+	}
+	for iter.Next() {
+		field := iter.Value()
+		attr := field.Attribute(attrName)
+		if attr.Err() != nil {
+			continue
+		}
+		name := iter.Selector().String()
+		pos := cuePosition(sources, field.Pos())
+
+		for i := 0; i < attr.NumArgs(); i++ {
+			role, _ := attr.Arg(i)
+			var target *string
+			switch role {
+			case roleInput:
+				target = &c.Input
+			case roleOutput:
+				target = &c.Output
+			case roleLogs:
+				target = &c.Logs
+			case roleCtx:
+				target = &c.Context
+			case roleDebug:
+				c.Debug = append(c.Debug, name)
+				continue
+			default:
+				return c, contractError(script, sources, fmt.Sprintf("@%s の引数 %q は使えません（%s のいずれか）", attrName, role,
+					strings.Join([]string{roleInput, roleOutput, roleLogs, roleDebug, roleCtx}, ", ")), pos)
+			}
+			if *target != "" {
+				return c, contractError(script, sources, fmt.Sprintf("@%s(%s) が %s と %s の両方にあります", attrName, role, *target, name), pos)
+			}
+			*target = name
+		}
+	}
+	return c, nil
+}
+
+func contractError(script scripting.Script, sources sourceFiles, msg string, pos scripting.Position) error {
+	if pos.File == "" {
+		pos.File = script.Name
+	}
+	return &scripting.Error{
+		Phase: scripting.PhaseCompile,
+		Diagnostics: []scripting.Diagnostic{{
+			Engine:    EngineName,
+			Severity:  scripting.SeverityError,
+			Code:      scripting.CodeEntryPoint,
+			Message:   "入出力の契約エラー: " + msg,
+			Pos:       pos,
+			CodeFrame: scripting.CodeFrame(sources[pos.File], pos, codeFrameLines),
+		}},
+	}
+}
@@ -0,0 +1,394 @@
+// Package cuelang はCUEの評価でデータを変換するエンジン
+package cuelang
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/cuecontext"
+	"cuelang.org/go/cue/parser"
+	"cuelang.org/go/cue/token"
+
+	"github.com/suinplayground/golang-embedded-scripting/kube"
+	"github.com/suinplayground/golang-embedded-scripting/scripting"
+)
+
+// EngineName はこのエンジンの名前
+const EngineName = "cue"
+
+// LineComment はヘッダーでパラメータ（@param）を宣言する行コメント
+const LineComment = "//"
+
+// Engine はCUEスクリプトを評価するscripting.Engineの実装
+//
+// スクリプトは入力のフィールド（デフォルトは inputConfigMaps）に入力を受け取り、
+// 出力のフィールド（デフォルトは mergedConfigMaps）に結果を出力する。
+// ログのフィールド（デフォルトは logs、任意）のリストは評価後にログとして記録する。
+// フィールドは Contract（@embedscript 属性か WithContract）で変更できる。
+type Engine struct {
+	logHandler slog.Handler
+	contract   Contract
+	modules    fs.FS
+	offline    bool
+	programs   *scripting.Cache
+}
+
+// Option はEngineの設定を変更する
+type Option func(*Engine)
+
+// WithLogHandler はスクリプトの logs フィールドのログを送るslogのハンドラを設定する
+//
+// 設定しなければログは scripting.Result.Logs に記録されるだけになる。
+func WithLogHandler(h slog.Handler) Option {
+	return func(e *Engine) {
+		e.logHandler = h
+	}
+}
+
+// WithCache はコンパイル済みのプログラムをcに保存し、同じスクリプトのコンパイルで使い回す
+//
+// コンパイルしたCUEの値と契約をキャッシュする。WithModules のCUEのファイルが
+// 変わっていれば（追加・削除を含む）コンパイルし直す。
+func WithCache(c *scripting.Cache) Option {
+	return func(e *Engine) {
+		e.programs = c
+	}
+}
+
+// New はCUEエンジンを作成する
+func New(opts ...Option) *Engine {
+	e := &Engine{}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+func (e *Engine) Name() string { return EngineName }
+
+// Compile はCUEスクリプトをコンパイルする
+//
+// WithModules があれば同じパッケージのファイルと import もまとめてコンパイルする。
+func (e *Engine) Compile(ctx context.Context, script scripting.Script) (scripting.Program, error) {
+	// 契約・モジュールの設定が違えばコンパイル結果も変わる
+	key := scripting.CacheKey(EngineName, script, fmt.Sprintf("%+v", e.contract), scripting.ModulesKey(e.modules), fmt.Sprint(e.offline))
+	if e.programs != nil {
+		cached, ok := e.programs.Get(key, func(v interface{}) bool {
+			return v.(*Program).unchanged(e.modules)
+		})
+		if ok {
+			return cached.(*Program).withLogHandler(e.logHandler), nil
+		}
+	}
+
+	cueCtx := cuecontext.New()
+
+	params, err := scripting.DeclaredParams(EngineName, script, LineComment)
+	if err != nil {
+		return nil, err
+	}
+
+	// CUEスクリプトをパース（構文エラーとそれ以外のエラーを区別する）
+	f, err := parser.ParseFile(script.Name, script.Source)
+	if err != nil {
+		return nil, cueError(script, sourceFiles{script.Name: script.Source}, scripting.PhaseCompile, scripting.CodeSyntax, err)
+	}
+
+	// CUEスクリプトをコンパイル
+	var (
+		value   cue.Value
+		sources sourceFiles
+	)
+	err = watchdog(ctx, script.Name, scripting.PhaseCompile, func() error {
+		v, files, err := e.build(cueCtx, script, f)
+		if err != nil {
+			return err
+		}
+		value, sources = v, files
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 入出力のフィールドを読み込み時に確認する
+	contract, err := resolveContract(script, sources, value, e.contract)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Program{
+		script:     script,
+		params:     params,
+		ctx:        cueCtx,
+		value:      value,
+		sources:    sources,
+		modules:    scripting.NewModuleDigests(script, sources),
+		contract:   contract,
+		logHandler: e.logHandler,
+		mu:         &sync.Mutex{},
+	}
+	if e.programs != nil {
+		e.programs.Put(key, p)
+	}
+	return p, nil
+}
+
+// build はスクリプト（WithModules があればそのパッケージ）をコンパイルしてエラーを確認する
+func (e *Engine) build(cueCtx *cue.Context, script scripting.Script, f *ast.File) (cue.Value, sourceFiles, error) {
+	sources := sourceFiles{script.Name: script.Source}
+	var value cue.Value
+	if e.modules == nil {
+		value = cueCtx.BuildFile(f)
+	} else {
+		// 同じパッケージのファイルと import を cue/load で読み込む
+		inst, files, err := e.loadInstance(script, f.PackageName())
+		if err != nil {
+			return cue.Value{}, nil, err
+		}
+		sources = files
+		value = cueCtx.BuildInstance(inst)
+	}
+	// Errは最初のエラーしか返さないので、Validateですべてのエラーを集める
+	if err := value.Validate(); err != nil {
+		return cue.Value{}, nil, cueError(script, sources, scripting.PhaseCompile, scripting.CodeCompile, err)
+	}
+	return value, sources, nil
+}
+
+// Program はコンパイル済みのCUEスクリプト
+type Program struct {
+	script  scripting.Script
+	params  []scripting.Param
+	ctx     *cue.Context
+	value   cue.Value
+	sources sourceFiles
+	// modules はWithModulesのCUEのファイルの内容のハッシュ（キャッシュの確認用）
+	modules    scripting.ModuleDigests
+	contract   Contract
+	logHandler slog.Handler
+
+	// cue.Contextは並行に使えないので評価を直列化する
+	// （タイムアウト後も残っている評価が終わるまで次の評価は始まらない）。
+	// キャッシュから返したProgramとcue.Contextを共有するのでポインタにする
+	mu *sync.Mutex
+}
+
+// withLogHandler はログの送り先だけを変えたProgramを返す（キャッシュしたProgramは共有する）
+func (p *Program) withLogHandler(h slog.Handler) *Program {
+	cp := *p
+	cp.logHandler = h
+	return &cp
+}
+
+// Run は入力を契約の入力のフィールドに設定してCUEを評価する
+func (p *Program) Run(ctx context.Context, objects []kube.Object, opts ...scripting.RunOption) (*scripting.Result, error) {
+	runOpts := scripting.NewRunOptions(opts...)
+	limits := runOpts.Limits
+	logHandler := runOpts.LogHandler
+	if logHandler == nil {
+		logHandler = p.logHandler
+	}
+
+	// メモリ割り当ての上限を超えたらctxが終了する
+	ctx, stopWatch := limits.WatchAlloc(ctx, EngineName, p.script.Name)
+	defer stopWatch()
+
+	// 宣言に合わせてパラメータを確認する（評価の前に）
+	runCtx := runOpts.Context(p.script.Name)
+	var err error
+	if runCtx.Params, err = scripting.ResolveParams(EngineName, p.script, p.params, runCtx.Params); err != nil {
+		return nil, err
+	}
+	scriptCtx, err := runCtx.Generic()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		outputs []kube.Object
+		entries []logEntry
+	)
+	err = watchdog(ctx, p.script.Name, scripting.PhaseRun, func() error {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+
+		var err error
+		outputs, entries, err = p.evaluate(objects, scriptCtx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 評価が終わってからログを記録する（中断した評価のログは記録しない）
+	logs := scripting.NewLogCollector(ctx, EngineName, p.script.Name, logHandler)
+	for _, e := range entries {
+		logs.Log(e.level, e.message, e.pos)
+	}
+
+	if err := limits.CheckOutput(EngineName, p.script.Name, outputs); err != nil {
+		return nil, err
+	}
+	return &scripting.Result{Objects: outputs, Logs: logs.Records()}, nil
+}
+
+// watchdog はfをgoroutineで実行し、ctxが終了したら完了を待たずにErrTimeout（または LimitError）を返す
+//
+// CUEの評価は途中で中断できないため、タイムアウト後もgoroutineは評価が終わるまで残る。
+func watchdog(ctx context.Context, script string, phase scripting.Phase, f func() error) error {
+	if ctx.Err() != nil {
+		return scripting.Interrupted(ctx, EngineName, script, phase)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- f()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return scripting.Interrupted(ctx, EngineName, script, phase)
+	}
+}
+
+// evaluate は入力とctxを設定してCUEを評価し、出力とログ・デバッグのフィールドのログを返す
+func (p *Program) evaluate(objects []kube.Object, scriptCtx map[string]interface{}) ([]kube.Object, []logEntry, error) {
+	// 入力のフィールドに値を設定
+	inputValue := p.ctx.Encode(kube.Generic(objects))
+	filled := p.value.FillPath(cue.ParsePath(p.contract.Input), inputValue)
+	if filled.Err() != nil {
+		return nil, nil, cueError(p.script, p.sources, scripting.PhaseRun, scripting.CodeRuntime, filled.Err())
+	}
+
+	// ctxのフィールドがあれば値を設定（#Context などの制約で確認される）
+	if ctxPath := cue.ParsePath(p.contract.Context); p.value.LookupPath(ctxPath).Exists() {
+		filled = filled.FillPath(ctxPath, p.ctx.Encode(scriptCtx))
+		if filled.Err() != nil {
+			return nil, nil, cueError(p.script, p.sources, scripting.PhaseRun, scripting.CodeRuntime, filled.Err())
+		}
+	}
+
+	// 出力のフィールドを取得
+	mergedValue := filled.LookupPath(cue.ParsePath(p.contract.Output))
+	if mergedValue.Err() != nil {
+		return nil, nil, cueError(p.script, p.sources, scripting.PhaseRun, scripting.CodeRuntime, mergedValue.Err())
+	}
+
+	// 結果をデコード
+	var mergedInterface []interface{}
+	if err := mergedValue.Decode(&mergedInterface); err != nil {
+		return nil, nil, cueError(p.script, p.sources, scripting.PhaseRun, scripting.CodeRuntime, err)
+	}
+
+	// Goのオブジェクトに変換
+	outputs, err := kube.ObjectsFromGeneric(mergedInterface)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	entries, err := decodeLogs(p.sources, filled.LookupPath(cue.ParsePath(p.contract.Logs)))
+	if err != nil {
+		return nil, nil, err
+	}
+	entries = append(entries, debugEntries(p.sources, filled, p.contract.Debug)...)
+	return outputs, entries, nil
+}
+
+// debugEntries はデバッグのフィールドの値をJSONにしてデバッグログにする（存在しないフィールドは読まない）
+func debugEntries(sources sourceFiles, v cue.Value, paths []string) []logEntry {
+	var entries []logEntry
+	for _, path := range paths {
+		field := v.LookupPath(cue.ParsePath(path))
+		if !field.Exists() {
+			continue
+		}
+		e := logEntry{level: slog.LevelDebug, pos: cuePosition(sources, field.Pos())}
+		if b, err := field.MarshalJSON(); err != nil {
+			e.level = slog.LevelWarn
+			e.message = fmt.Sprintf("%s: 値を読み込めません: %v", path, err)
+		} else {
+			e.message = fmt.Sprintf("%s: %s", path, b)
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+// logEntry は logs フィールドの1件
+type logEntry struct {
+	level   slog.Level
+	message string
+	pos     scripting.Position
+}
+
+// decodeLogs は logs フィールドを読み込む（フィールドがなければログなし）
+//
+// 各要素は文字列（infoレベル）か {level: "debug"|"info"|"warn"|"error", message: string}。
+func decodeLogs(sources sourceFiles, v cue.Value) ([]logEntry, error) {
+	if !v.Exists() {
+		return nil, nil
+	}
+	iter, err := v.List()
+	if err != nil {
+		return nil, fmt.Errorf("logs はリストにしてください: %w", err)
+	}
+
+	var entries []logEntry
+	for iter.Next() {
+		elem := iter.Value()
+		e := logEntry{level: slog.LevelInfo, pos: cuePosition(sources, elem.Pos())}
+
+		if elem.Kind() == cue.StringKind {
+			e.message, _ = elem.String()
+			entries = append(entries, e)
+			continue
+		}
+
+		msgValue := elem.LookupPath(cue.ParsePath("message"))
+		msg, err := msgValue.String()
+		if err != nil {
+			return nil, fmt.Errorf("logs[%d].message: %w", len(entries), err)
+		}
+		e.message = msg
+		if !e.pos.IsValid() {
+			e.pos = cuePosition(sources, msgValue.Pos())
+		}
+		if level, err := elem.LookupPath(cue.ParsePath("level")).String(); err == nil {
+			if err := e.level.UnmarshalText([]byte(level)); err != nil {
+				return nil, fmt.Errorf("logs[%d].level: %w", len(entries), err)
+			}
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// cuePosition はCUEの位置を診断の位置にする
+//
+// CUEの列はバイト単位なので、sources の行から文字（コードポイント）単位の列に直す。
+func cuePosition(sources sourceFiles, pos token.Pos) scripting.Position {
+	if !pos.IsValid() {
+		return scripting.Position{}
+	}
+	file := fileName(pos.Filename())
+	return scripting.Position{File: file, Line: pos.Line(), Column: runeColumn(sources[file], pos.Line(), pos.Column())}
+}
+
+// runeColumn はバイト単位の列（1始まり）を文字単位の列にする（行が見つからなければそのまま返す）
+func runeColumn(source string, line, column int) int {
+	lines := strings.Split(source, "\n")
+	if line < 1 || line > len(lines) || column < 1 || column-1 > len(lines[line-1]) {
+		return column
+	}
+	return utf8.RuneCountInString(lines[line-1][:column-1]) + 1
+}
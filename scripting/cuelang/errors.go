@@ -0,0 +1,111 @@
+package cuelang
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	cueerrors "cuelang.org/go/cue/errors"
+
+	"github.com/suinplayground/golang-embedded-scripting/scripting"
+)
+
+// コードフレームに表示する前後の行数
+const codeFrameLines = 2
+
+// cueError はCUEのエラーを1件ずつcodeの診断にする
+//
+// 各エラーの最初の位置をエラーの位置とし、衝突した値など残りの位置は補足にする。
+// タイムアウト・上限超過のエラーはそのまま返す。
+// コードフレームは sources のエラーの位置のファイルから作る。
+func cueError(script scripting.Script, sources sourceFiles, phase scripting.Phase, code string, err error) error {
+	var timeout *scripting.ErrTimeout
+	var limitErr *scripting.LimitError
+	if errors.As(err, &timeout) || errors.As(err, &limitErr) {
+		return err
+	}
+
+	var diagnostics []scripting.Diagnostic
+	// 同じエラーが複数の経路から報告されることがあるので重複を除く
+	for _, e := range cueerrors.Errors(cueerrors.Sanitize(cueerrors.Promote(err, ""))) {
+		d := scripting.Diagnostic{
+			Engine:   EngineName,
+			Severity: scripting.SeverityError,
+			Code:     code,
+			Message:  errorMessage(e),
+			Pos:      scripting.Position{File: script.Name},
+		}
+		for i, p := range cueerrors.Positions(e) {
+			pos := cuePosition(sources, p)
+			if i == 0 {
+				d.Pos = pos
+				d.CodeFrame = scripting.CodeFrame(sources[pos.File], pos, codeFrameLines)
+				continue
+			}
+			d.Notes = append(d.Notes, scripting.Note{
+				Message: "関連する値: " + sourceLine(sources[pos.File], pos),
+				Pos:     pos,
+			})
+		}
+		diagnostics = append(diagnostics, d)
+	}
+	return &scripting.Error{Phase: phase, Diagnostics: diagnostics}
+}
+
+// errorMessage はエラーのフィールドのパスとメッセージを返す（"a.b: conflicting values ..."）
+func errorMessage(e cueerrors.Error) string {
+	format, args := e.Msg()
+	msg := fmt.Sprintf(format, args...)
+	if path := e.Path(); len(path) > 0 {
+		msg = formatPath(path) + ": " + msg
+	}
+	// cue/load のエラーは原因（見つからないパッケージなど）を包んでいる
+	var cause cueerrors.Error
+	if errors.As(errors.Unwrap(e), &cause) {
+		msg += ": " + errorMessage(cause)
+	} else if err := errors.Unwrap(e); err != nil {
+		msg += ": " + err.Error()
+	}
+	// cue/load のエラーはオーバーレイのパスを含む
+	return strings.ReplaceAll(msg, overlayRoot+string(filepath.Separator), "")
+}
+
+// formatPath はエラーのフィールドのパスを "a.b[0].c" の形にする
+//
+// CUEはリストの添字を数字だけの要素で返す（数字のフィールド名はクォートされる）。
+func formatPath(path []string) string {
+	var b strings.Builder
+	for i, sel := range path {
+		switch {
+		case isIndex(sel):
+			b.WriteString("[" + sel + "]")
+		case i > 0:
+			b.WriteString("." + sel)
+		default:
+			b.WriteString(sel)
+		}
+	}
+	return b.String()
+}
+
+func isIndex(sel string) bool {
+	if sel == "" {
+		return false
+	}
+	for _, r := range sel {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// sourceLine はposの行のソースコードを前後の空白を除いて返す
+func sourceLine(source string, pos scripting.Position) string {
+	lines := strings.Split(source, "\n")
+	if !pos.IsValid() || pos.Line > len(lines) {
+		return ""
+	}
+	return strings.TrimSpace(lines[pos.Line-1])
+}
@@ -0,0 +1,86 @@
+package cuelang
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/suinplayground/golang-embedded-scripting/scripting"
+)
+
+func TestFormatPath(t *testing.T) {
+	tests := []struct {
+		path []string
+		want string
+	}{
+		{path: []string{"a", "b"}, want: "a.b"},
+		{path: []string{"out", "1", "metadata", "name"}, want: "out[1].metadata.name"},
+		{path: []string{"a", "0", "0"}, want: "a[0][0]"},
+		{path: []string{`"a-b"`, "2"}, want: `"a-b"[2]`},
+		{path: []string{`"1"`, "x"}, want: `"1".x`},
+	}
+	for _, tt := range tests {
+		if got := formatPath(tt.path); got != tt.want {
+			t.Errorf("formatPath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestRuneColumn(t *testing.T) {
+	const source = "a: 1\nb: \"日本語\" & 1 // コメント\n"
+	tests := []struct {
+		name         string
+		line, column int
+		want         int
+	}{
+		{name: "ASCII", line: 1, column: 4, want: 4},
+		{name: "マルチバイトの後", line: 2, column: 16, want: 10},
+		{name: "行の外", line: 5, column: 3, want: 3},
+		{name: "列が行より長い", line: 1, column: 40, want: 40},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := runeColumn(source, tt.line, tt.column); got != tt.want {
+				t.Fatalf("runeColumn() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiagnosticPosition(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  string
+		message string
+		pos     scripting.Position
+	}{
+		{
+			name:    "日本語の後の列は文字単位",
+			source:  "input: _\n// 日本語\nout: { \"名前\": \"a\" & \"b\" }\n",
+			message: `out.名前: conflicting values "b" and "a"`,
+			pos:     scripting.Position{File: "main.cue", Line: 3, Column: 14},
+		},
+		{
+			name:    "リストの添字",
+			source:  "input: _\nout: [{name: \"a\"}, {name: \"b\" & \"c\"}]\n",
+			message: `out[1].name: conflicting values "c" and "b"`,
+			pos:     scripting.Position{File: "main.cue", Line: 2, Column: 27},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New().Compile(context.Background(), scripting.Script{Name: "main.cue", Source: tt.source})
+			var serr *scripting.Error
+			if !errors.As(err, &serr) {
+				t.Fatalf("err = %v, want *scripting.Error", err)
+			}
+			d := serr.Diagnostics[0]
+			if d.Message != tt.message {
+				t.Errorf("Message = %q, want %q", d.Message, tt.message)
+			}
+			if d.Pos != tt.pos {
+				t.Errorf("Pos = %+v, want %+v", d.Pos, tt.pos)
+			}
+		})
+	}
+}
@@ -0,0 +1,218 @@
+package cuelang
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"cuelang.org/go/cue/build"
+	"cuelang.org/go/cue/load"
+	"cuelang.org/go/mod/modfile"
+	"cuelang.org/go/mod/module"
+
+	"github.com/suinplayground/golang-embedded-scripting/scripting"
+)
+
+// WithModules はスクリプトのパッケージと import を cue/load で読み込むファイルシステムを設定する
+//
+// スクリプトは fsys の script.Name の位置にあるものとして、同じディレクトリの
+// 同じパッケージのファイルとまとめてコンパイルする。fsys のルートに
+// cue.mod/module.cue があればCUEモジュールとして import を解決する。
+// 設定しなければスクリプトの1ファイルだけをコンパイルする。
+//
+// ディレクトリを読む場合は os.DirFS、埋め込む場合は embed.FS を渡す。
+func WithModules(fsys fs.FS) Option {
+	return func(e *Engine) {
+		e.modules = fsys
+	}
+}
+
+// WithOffline は依存モジュールをレジストリから取得せず、fsys の cue.mod/vendor から読む
+//
+// 依存モジュールは cue.mod/vendor/<モジュールのパス>@<バージョン>
+// （例: cue.mod/vendor/example.com/schemas@v0.1.0）に置く。
+// 設定しなければ CUE_REGISTRY などの環境変数のレジストリから取得する。
+func WithOffline() Option {
+	return func(e *Engine) {
+		e.offline = true
+	}
+}
+
+// vendorDir はオフラインで読む依存モジュールのディレクトリ
+const vendorDir = "cue.mod/vendor"
+
+// overlayRoot は fsys のファイルを cue/load に渡す仮想的なディレクトリ
+//
+// cue/load は実際のファイルシステムを読むので、fsys のファイルはすべて
+// このディレクトリの下のオーバーレイとして渡す。
+var overlayRoot = filepath.FromSlash("/embedscript")
+
+// sourceFiles はコンパイルしたファイルのソース（ファイル名 → ソース、コードフレームの表示用）
+type sourceFiles map[string]string
+
+// fileName は cue/load のファイル名を fsys 内のパスにする
+func fileName(name string) string {
+	if rel, ok := strings.CutPrefix(name, overlayRoot+string(filepath.Separator)); ok {
+		return filepath.ToSlash(rel)
+	}
+	return name
+}
+
+// loadInstance はスクリプトのパッケージ（パッケージ名 pkg）を cue/load で読み込む
+func (e *Engine) loadInstance(script scripting.Script, pkg string) (*build.Instance, sourceFiles, error) {
+	name := path.Clean(script.Name)
+	if !fs.ValidPath(name) || name == "." {
+		return nil, nil, loadError(script, fmt.Sprintf("スクリプト名 %q はモジュールの中のパスにしてください", script.Name))
+	}
+
+	// CUEのファイルをすべてオーバーレイにする（スクリプトは fsys のファイルより優先する）
+	overlay := map[string]load.Source{}
+	sources := sourceFiles{}
+	err := fs.WalkDir(e.modules, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || path.Ext(p) != ".cue" {
+			return err
+		}
+		data, err := fs.ReadFile(e.modules, p)
+		if err != nil {
+			return err
+		}
+		overlay[overlayPath(p)] = load.FromBytes(data)
+		sources[p] = string(data)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, loadError(script, fmt.Sprintf("モジュールの読み込みエラー: %v", err))
+	}
+	overlay[overlayPath(name)] = load.FromString(script.Source)
+	sources[name] = script.Source
+
+	// パッケージ名のないスクリプトは同じくパッケージ名のないファイルとまとめる
+	if pkg == "" {
+		pkg = "_"
+	}
+	cfg := &load.Config{
+		Dir:        overlayPath(path.Dir(name)),
+		ModuleRoot: overlayRoot,
+		Package:    pkg,
+		Overlay:    overlay,
+	}
+	if e.offline {
+		cfg.Registry = &vendorRegistry{fsys: e.modules}
+	}
+
+	insts := load.Instances([]string{"."}, cfg)
+	if len(insts) != 1 {
+		return nil, nil, loadError(script, fmt.Sprintf("パッケージ %s を読み込めません", pkg))
+	}
+	if err := insts[0].Err; err != nil {
+		return nil, nil, cueError(script, sources, scripting.PhaseCompile, scripting.CodeLoad, err)
+	}
+	return insts[0], sources, nil
+}
+
+// unchanged は fsys のCUEのファイルがコンパイルしたときと同じか（追加・削除を含む）を返す
+func (p *Program) unchanged(fsys fs.FS) bool {
+	if fsys == nil {
+		return len(p.modules) == 0
+	}
+	if !p.modules.Unchanged(fsys) {
+		return false
+	}
+	name := path.Clean(p.script.Name)
+	n := 0
+	err := fs.WalkDir(fsys, ".", func(file string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() && path.Ext(file) == ".cue" && file != name {
+			n++
+		}
+		return err
+	})
+	return err == nil && n == len(p.modules)
+}
+
+func overlayPath(p string) string {
+	return filepath.Join(overlayRoot, filepath.FromSlash(p))
+}
+
+func loadError(script scripting.Script, msg string) error {
+	return &scripting.Error{
+		Phase: scripting.PhaseCompile,
+		Diagnostics: []scripting.Diagnostic{{
+			Engine:   EngineName,
+			Severity: scripting.SeverityError,
+			Code:     scripting.CodeLoad,
+			Message:  msg,
+			Pos:      scripting.Position{File: script.Name},
+		}},
+	}
+}
+
+// vendorRegistry は依存モジュールを fsys の cue.mod/vendor から読む modconfig.Registry
+type vendorRegistry struct {
+	fsys fs.FS
+}
+
+// vendorFS は依存モジュールのファイルシステム
+//
+// cue/load はモジュールのファイルを OSRoot からのパスで読むので、
+// オーバーレイのディレクトリを返す。
+type vendorFS struct {
+	fs.FS
+}
+
+func (vendorFS) OSRoot() string { return overlayRoot }
+
+func (r *vendorRegistry) dir(m module.Version) string {
+	return path.Join(vendorDir, m.BasePath()+"@"+m.Version())
+}
+
+func (r *vendorRegistry) Fetch(ctx context.Context, m module.Version) (module.SourceLoc, error) {
+	dir := r.dir(m)
+	if info, err := fs.Stat(r.fsys, dir); err != nil || !info.IsDir() {
+		return module.SourceLoc{}, fmt.Errorf("モジュール %s がありません（オフラインでは %s に置いてください）", m, dir)
+	}
+	return module.SourceLoc{FS: vendorFS{r.fsys}, Dir: dir}, nil
+}
+
+// Requirements は依存モジュールの cue.mod/module.cue の deps を返す
+func (r *vendorRegistry) Requirements(ctx context.Context, m module.Version) ([]module.Version, error) {
+	file := path.Join(r.dir(m), "cue.mod/module.cue")
+	data, err := fs.ReadFile(r.fsys, file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	mf, err := modfile.ParseNonStrict(data, file)
+	if err != nil {
+		return nil, err
+	}
+	return mf.DepVersions(), nil
+}
+
+// ModuleVersions は cue.mod/vendor にあるメジャーバージョンが同じバージョンを返す
+func (r *vendorRegistry) ModuleVersions(ctx context.Context, mpath string) ([]string, error) {
+	base, major, ok := module.SplitPathVersion(mpath)
+	if !ok {
+		return nil, fmt.Errorf("モジュールのパス %q にメジャーバージョンがありません", mpath)
+	}
+	entries, err := fs.ReadDir(r.fsys, path.Join(vendorDir, path.Dir(base)))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var versions []string
+	for _, entry := range entries {
+		v, ok := strings.CutPrefix(entry.Name(), path.Base(base)+"@")
+		if ok && entry.IsDir() && strings.SplitN(v, ".", 2)[0] == major {
+			versions = append(versions, v)
+		}
+	}
+	return versions, nil
+}
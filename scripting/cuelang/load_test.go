@@ -0,0 +1,150 @@
+package cuelang
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/suinplayground/golang-embedded-scripting/scripting"
+)
+
+// vendored は cue.mod/vendor に置いた依存モジュール example.com/schemas@v0.1.0
+var vendored = fstest.MapFS{
+	"cue.mod/vendor/example.com/schemas@v0.1.0/cue.mod/module.cue": {Data: []byte("module: \"example.com/schemas@v0\"\nlanguage: version: \"v0.11.0\"\n")},
+	"cue.mod/vendor/example.com/schemas@v0.1.0/schemas.cue":        {Data: []byte("package schemas\n\n#Name: \"vendored\"\n")},
+}
+
+func TestLoadInstance(t *testing.T) {
+	const module = "module: \"example.com/app@v0\"\nlanguage: version: \"v0.11.0\"\ndeps: \"example.com/schemas@v0\": v: \"v0.1.0\"\n"
+	files := func(extra fstest.MapFS) fstest.MapFS {
+		fsys := fstest.MapFS{
+			"cue.mod/module.cue": {Data: []byte(module)},
+			"lib/lib.cue":        {Data: []byte("package lib\n\n#Name: \"lib\"\n")},
+			"main/name.cue":      {Data: []byte("package main\n\n#Name: \"package\"\n")},
+			"main/other.cue":     {Data: []byte("package other\n\n#Name: \"other\"\n")},
+		}
+		for k, v := range extra {
+			fsys[k] = v
+		}
+		return fsys
+	}
+	output := "inputConfigMaps: _\nmergedConfigMaps: [{apiVersion: \"v1\", kind: \"ConfigMap\", metadata: name: #Name}]\n"
+	tests := []struct {
+		name    string
+		fsys    fstest.MapFS
+		script  scripting.Script
+		want    string
+		message string
+	}{
+		{
+			name:   "同じパッケージのファイル",
+			fsys:   files(nil),
+			script: scripting.Script{Name: "main/main.cue", Source: "package main\n\n" + output},
+			want:   "package",
+		},
+		{
+			name:   "モジュール内のimport",
+			fsys:   files(nil),
+			script: scripting.Script{Name: "main/main.cue", Source: "package x\n\nimport \"example.com/app/lib\"\n\n#Name: lib.#Name\n" + output},
+			want:   "lib",
+		},
+		{
+			name:   "vendorの依存モジュール",
+			fsys:   files(vendored),
+			script: scripting.Script{Name: "main/main.cue", Source: "package x\n\nimport \"example.com/schemas\"\n\n#Name: schemas.#Name\n" + output},
+			want:   "vendored",
+		},
+		{
+			name:    "vendorにない依存モジュール",
+			fsys:    files(nil),
+			script:  scripting.Script{Name: "main/main.cue", Source: "package x\n\nimport \"example.com/schemas\"\n\n#Name: schemas.#Name\n" + output},
+			message: "モジュール example.com/schemas@v0.1.0 がありません（オフラインでは cue.mod/vendor/example.com/schemas@v0.1.0 に置いてください）",
+		},
+		{
+			name:    "モジュールの外のスクリプト",
+			fsys:    files(nil),
+			script:  scripting.Script{Name: "../main.cue", Source: output},
+			message: `スクリプト名 "../main.cue" はモジュールの中のパスにしてください`,
+		},
+		{
+			name:    "途中でモジュールの外に出るスクリプト",
+			fsys:    files(nil),
+			script:  scripting.Script{Name: "main/../../main.cue", Source: output},
+			message: `スクリプト名 "main/../../main.cue" はモジュールの中のパスにしてください`,
+		},
+		{
+			name:    "絶対パスのスクリプト",
+			fsys:    files(nil),
+			script:  scripting.Script{Name: "/etc/main.cue", Source: output},
+			message: `スクリプト名 "/etc/main.cue" はモジュールの中のパスにしてください`,
+		},
+		{
+			name:    "ファイルでないスクリプト名",
+			fsys:    files(nil),
+			script:  scripting.Script{Name: ".", Source: output},
+			message: `スクリプト名 "." はモジュールの中のパスにしてください`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			program, err := New(WithModules(tt.fsys), WithOffline()).Compile(ctx, tt.script)
+			if tt.message != "" {
+				var serr *scripting.Error
+				if !errors.As(err, &serr) {
+					t.Fatalf("err = %v, want *scripting.Error", err)
+				}
+				if d := serr.Diagnostics[0]; d.Code != scripting.CodeLoad || !strings.Contains(d.Message, tt.message) {
+					t.Fatalf("Code, Message = %q, %q, want %q, %q", d.Code, d.Message, scripting.CodeLoad, tt.message)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Compile: %v", err)
+			}
+			result, err := program.Run(ctx, nil)
+			if err != nil {
+				t.Fatalf("Run: %v", err)
+			}
+			if got := result.Objects[0].Name(); got != tt.want {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestLoadCacheStale はパッケージのファイルが変わったらキャッシュを捨ててコンパイルし直すことを確認する
+func TestLoadCacheStale(t *testing.T) {
+	fsys := fstest.MapFS{
+		"cue.mod/module.cue": {Data: []byte("module: \"example.com/app@v0\"\nlanguage: version: \"v0.11.0\"\n")},
+		"name.cue":           {Data: []byte("package main\n\n#Name: \"one\"\n")},
+	}
+	script := scripting.Script{
+		Name:   "main.cue",
+		Source: "package main\n\ninputConfigMaps: _\nmergedConfigMaps: [{apiVersion: \"v1\", kind: \"ConfigMap\", metadata: name: #Name}]\n",
+	}
+	cache := scripting.NewCache(0)
+	e := New(WithModules(fsys), WithCache(cache))
+
+	var names []string
+	for _, name := range []string{"one", "one", "two"} {
+		fsys["name.cue"] = &fstest.MapFile{Data: []byte("package main\n\n#Name: \"" + name + "\"\n")}
+		program, err := e.Compile(context.Background(), script)
+		if err != nil {
+			t.Fatalf("Compile: %v", err)
+		}
+		result, err := program.Run(context.Background(), nil)
+		if err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+		names = append(names, result.Objects[0].Name())
+	}
+	if names[0] != "one" || names[1] != "one" || names[2] != "two" {
+		t.Fatalf("names = %q, want [one one two]", names)
+	}
+	if stats := cache.Stats(); stats.Hits != 1 || stats.Stale != 1 {
+		t.Fatalf("Hits=%d Stale=%d, want 1, 1", stats.Hits, stats.Stale)
+	}
+}
@@ -0,0 +1,234 @@
+package scripting
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Severity は診断メッセージの重要度
+type Severity int
+
+const (
+	SeverityInfo Severity = iota
+	SeverityWarning
+	SeverityError
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	default:
+		return fmt.Sprintf("Severity(%d)", int(s))
+	}
+}
+
+// 診断の種類を表すコード（エンジン共通）
+//
+// TypeScriptの警告などエンジン固有のコード（esbuildのメッセージIDなど）が入ることもある。
+const (
+	// CodeSyntax は構文エラー
+	CodeSyntax = "syntax-error"
+	// CodeResolve は名前解決のエラー（未定義の名前など）
+	CodeResolve = "resolve-error"
+	// CodeCompile はその他のコンパイル時のエラー（CUEの制約の衝突など）
+	CodeCompile = "compile-error"
+	// CodeRuntime は実行・評価時のエラー
+	CodeRuntime = "runtime-error"
+	// CodeTimeout はタイムアウト・キャンセル（*ErrTimeout）
+	CodeTimeout = "timeout"
+	// CodeLimit はリソースの上限超過（*LimitError）
+	CodeLimit = "limit-exceeded"
+	// CodeEntryPoint はエントリポイント（transform 関数）がないエラー
+	CodeEntryPoint = "entry-point"
+	// CodeParam はパラメータの宣言・値のエラー
+	CodeParam = "invalid-param"
+	// CodeLoad はモジュールの読み込みのエラー（load()・import で見つからない・循環など）
+	CodeLoad = "load-error"
+)
+
+// StackFrame はスタックトレースの1フレーム
+type StackFrame struct {
+	// Function は関数名（トップレベルは空）
+	Function string
+	// Pos はスクリプト内の位置（ネイティブ関数では無効）
+	Pos Position
+}
+
+func (f StackFrame) String() string {
+	name := f.Function
+	if name == "" {
+		name = "<anonymous>"
+	}
+	if !f.Pos.IsValid() {
+		return name + " (native)"
+	}
+	return fmt.Sprintf("%s (%s)", name, f.Pos)
+}
+
+// Note は診断メッセージの補足（関連する位置や修正案）
+type Note struct {
+	Message string
+	// Pos は補足の対象の位置（不明なら無効）
+	Pos Position
+}
+
+func (n Note) String() string {
+	if n.Pos.IsValid() {
+		return fmt.Sprintf("%s: %s", n.Pos, n.Message)
+	}
+	return n.Message
+}
+
+// Diagnostic はコンパイル・実行時にエンジンが報告する診断メッセージ
+type Diagnostic struct {
+	Engine   string
+	Severity Severity
+	// Code は診断の種類（Code* の定数など、不明なら空）
+	Code    string
+	Message string
+	// Pos は問題のある位置（不明なら無効）
+	Pos Position
+	// End は問題のある範囲の終わり（分かる場合のみ、Posと同じ行・列なら範囲なし）
+	End Position
+	// Stack は実行時エラーのスタックトレース（呼び出し先が先頭）
+	Stack []StackFrame
+	// CodeFrame はPosの前後のソースコード（CodeFrame関数で作る）
+	CodeFrame string
+	// Notes は補足のメッセージ
+	Notes []Note
+}
+
+func (d Diagnostic) String() string {
+	var b strings.Builder
+	if d.Engine != "" {
+		fmt.Fprintf(&b, "[%s] ", d.Engine)
+	}
+	b.WriteString(d.Severity.String())
+	if d.Pos.IsValid() {
+		fmt.Fprintf(&b, ": %s", d.Pos)
+	}
+	fmt.Fprintf(&b, ": %s", d.Message)
+	return b.String()
+}
+
+// Format はスタックトレースとコードフレームを含む複数行の表現を返す
+func (d Diagnostic) Format() string {
+	var b strings.Builder
+	b.WriteString(d.String())
+	for _, f := range d.Stack {
+		fmt.Fprintf(&b, "\n    at %s", f)
+	}
+	if d.CodeFrame != "" {
+		b.WriteString("\n\n")
+		b.WriteString(d.CodeFrame)
+	}
+	for _, n := range d.Notes {
+		fmt.Fprintf(&b, "\n  note: %s", n)
+	}
+	return b.String()
+}
+
+// Error はスクリプトのコンパイル・実行の失敗を表す
+//
+// Diagnostics には少なくとも1つの SeverityError の診断が含まれる。
+type Error struct {
+	Phase       Phase
+	Diagnostics []Diagnostic
+}
+
+func (e *Error) Error() string {
+	parts := make([]string, len(e.Diagnostics))
+	for i, d := range e.Diagnostics {
+		parts[i] = d.Format()
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// Diagnostics はエラーを診断のリストにする
+//
+// *Error はその診断、*ErrTimeout と *LimitError は1件の診断になる。
+// それ以外のエラーはエンジンと位置が不明な1件の診断になる。
+func Diagnostics(err error) []Diagnostic {
+	if err == nil {
+		return nil
+	}
+
+	var scriptErr *Error
+	var timeout *ErrTimeout
+	var limitErr *LimitError
+	switch {
+	case errors.As(err, &scriptErr):
+		return scriptErr.Diagnostics
+	case errors.As(err, &timeout):
+		return []Diagnostic{{
+			Engine:   timeout.Engine,
+			Severity: SeverityError,
+			Code:     CodeTimeout,
+			Message:  timeout.message(),
+			Pos:      Position{File: timeout.Script},
+		}}
+	case errors.As(err, &limitErr):
+		return []Diagnostic{{
+			Engine:   limitErr.Engine,
+			Severity: SeverityError,
+			Code:     CodeLimit,
+			Message:  limitErr.message(),
+			Pos:      Position{File: limitErr.Script},
+		}}
+	default:
+		return []Diagnostic{{Severity: SeverityError, Message: err.Error()}}
+	}
+}
+
+// CodeFrame はposの行の前後contextLines行のソースコードを、行番号と列を指す ^ 付きで返す
+//
+//	  1 | function f() {
+//	→ 2 |   throw new Error("x");
+//	    |         ^
+//	  3 | }
+func CodeFrame(source string, pos Position, contextLines int) string {
+	if !pos.IsValid() {
+		return ""
+	}
+	// 末尾の改行の後ろは空行として表示しない
+	lines := strings.Split(strings.TrimSuffix(source, "\n"), "\n")
+	if pos.Line > len(lines) {
+		return ""
+	}
+
+	start := max(1, pos.Line-contextLines)
+	end := min(len(lines), pos.Line+contextLines)
+	width := len(fmt.Sprint(end))
+
+	var b strings.Builder
+	for n := start; n <= end; n++ {
+		line := strings.TrimRight(lines[n-1], "\r")
+		marker := "  "
+		if n == pos.Line {
+			marker = "→ "
+		}
+		fmt.Fprintf(&b, "%s%*d | %s\n", marker, width, n, line)
+		if n == pos.Line && pos.Column > 0 {
+			// タブは幅を揃えるためそのまま残す
+			var pad strings.Builder
+			for i, r := range []rune(line) {
+				if i >= pos.Column-1 {
+					break
+				}
+				if r == '\t' {
+					pad.WriteRune('\t')
+				} else {
+					pad.WriteRune(' ')
+				}
+			}
+			fmt.Fprintf(&b, "  %*s | %s^\n", width, "", pad.String())
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
@@ -0,0 +1,68 @@
+package scripting
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Phase はスクリプトの処理段階
+type Phase string
+
+const (
+	// PhaseCompile はトランスパイル・パース・コンパイル
+	PhaseCompile Phase = "compile"
+	// PhaseRun はスクリプトの実行・評価
+	PhaseRun Phase = "run"
+)
+
+// ErrTimeout はcontextの期限切れ・キャンセルでスクリプトを中断したことを表す
+//
+// Unwrap は context.DeadlineExceeded または context.Canceled（または
+// context.WithCancelCause などで設定した原因）を返すので、errors.Is で判定できる。
+type ErrTimeout struct {
+	// Engine はエンジン名（"typescript", "starlark", "cue"）
+	Engine string
+	// Script はスクリプト名
+	Script string
+	// Phase は中断した処理段階
+	Phase Phase
+	// Cause は中断の原因（context.Cause の値）
+	Cause error
+}
+
+// NewTimeout はctxの中断理由からErrTimeoutを作成する
+func NewTimeout(ctx context.Context, engine, script string, phase Phase) *ErrTimeout {
+	return &ErrTimeout{
+		Engine: engine,
+		Script: script,
+		Phase:  phase,
+		Cause:  context.Cause(ctx),
+	}
+}
+
+func (p Phase) label() string {
+	switch p {
+	case PhaseCompile:
+		return "コンパイル"
+	case PhaseRun:
+		return "実行"
+	default:
+		return string(p)
+	}
+}
+
+func (e *ErrTimeout) Error() string {
+	return fmt.Sprintf("[%s] %s の%s", e.Engine, e.Script, e.message())
+}
+
+// message はエンジン名・スクリプト名を除いたメッセージ
+func (e *ErrTimeout) message() string {
+	reason := "タイムアウトしました"
+	if errors.Is(e.Cause, context.Canceled) {
+		reason = "キャンセルされました"
+	}
+	return fmt.Sprintf("%sが%s: %v", e.Phase.label(), reason, e.Cause)
+}
+
+func (e *ErrTimeout) Unwrap() error { return e.Cause }
@@ -0,0 +1,196 @@
+package scripting
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"runtime/metrics"
+	"time"
+
+	"github.com/suinplayground/golang-embedded-scripting/kube"
+)
+
+// Limits は1回の実行で使えるリソースの上限（0の項目は無制限）
+type Limits struct {
+	// MaxSteps はStarlarkの実行ステップ数の上限（Starlarkのみ）
+	MaxSteps uint64
+
+	// MaxCallStackSize は関数呼び出しの深さの上限（TypeScriptのみ）
+	MaxCallStackSize int
+
+	// MaxAllocBytes は実行中にヒープに割り当てるバイト数の上限。
+	// プロセス全体の割り当て量を監視するので、並行して実行している
+	// 他の処理の割り当ても数えられる（目安として使うこと）
+	MaxAllocBytes uint64
+
+	// MaxObjects は出力オブジェクト数の上限
+	MaxObjects int
+
+	// MaxDataBytes は出力オブジェクト1つあたりの data（binaryData）のバイト数の上限
+	MaxDataBytes int
+
+	// MaxOutputBytes は出力全体をJSONにしたときのバイト数の上限
+	MaxOutputBytes int
+}
+
+// Limit は上限の種類
+type Limit string
+
+const (
+	LimitSteps       Limit = "steps"
+	LimitCallStack   Limit = "call-stack"
+	LimitAlloc       Limit = "alloc-bytes"
+	LimitObjects     Limit = "objects"
+	LimitDataBytes   Limit = "data-bytes"
+	LimitOutputBytes Limit = "output-bytes"
+)
+
+// LimitError はスクリプトがリソースの上限を超えたことを表す
+type LimitError struct {
+	Engine string
+	Script string
+	// Limit は超えた上限の種類
+	Limit Limit
+	// Max は設定された上限
+	Max uint64
+	// Used は使用量（上限を超えた時点の値）
+	Used uint64
+	// Object は上限を超えた出力オブジェクト（data-bytesのみ、"Kind namespace/name"）
+	Object string
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("[%s] %s: %s", e.Engine, e.Script, e.message())
+}
+
+// message はエンジン名・スクリプト名を除いたメッセージ
+func (e *LimitError) message() string {
+	msg := fmt.Sprintf("%s の上限を超えました（上限 %d, 使用量 %d）", e.Limit, e.Max, e.Used)
+	if e.Object != "" {
+		msg = e.Object + ": " + msg
+	}
+	return msg
+}
+
+// Interrupted はctxの終了で中断したスクリプトのエラーを返す
+//
+// ctxが WatchAlloc で上限超過により終了した場合は *LimitError、
+// それ以外は *ErrTimeout になる。
+func Interrupted(ctx context.Context, engine, script string, phase Phase) error {
+	var limitErr *LimitError
+	if errors.As(context.Cause(ctx), &limitErr) {
+		return limitErr
+	}
+	return NewTimeout(ctx, engine, script, phase)
+}
+
+// allocPollInterval はヒープ割り当て量を確認する間隔
+const allocPollInterval = 5 * time.Millisecond
+
+// WatchAlloc は実行中のヒープ割り当て量を監視し、MaxAllocBytesを超えたら
+// *LimitError を原因として終了するcontextを返す
+//
+// 返されたstopは監視を止める。MaxAllocBytesが0なら何もしない。
+func (l Limits) WatchAlloc(ctx context.Context, engine, script string) (context.Context, func()) {
+	if l.MaxAllocBytes == 0 {
+		return ctx, func() {}
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	done := make(chan struct{})
+	start := heapAllocs()
+
+	go func() {
+		ticker := time.NewTicker(allocPollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if used := heapAllocs() - start; used > l.MaxAllocBytes {
+					cancel(&LimitError{
+						Engine: engine,
+						Script: script,
+						Limit:  LimitAlloc,
+						Max:    l.MaxAllocBytes,
+						Used:   used,
+					})
+					return
+				}
+			}
+		}
+	}()
+
+	return ctx, func() {
+		close(done)
+		cancel(nil)
+	}
+}
+
+// プロセス起動からの累積ヒープ割り当てバイト数
+func heapAllocs() uint64 {
+	sample := []metrics.Sample{{Name: "/gc/heap/allocs:bytes"}}
+	metrics.Read(sample)
+	if sample[0].Value.Kind() != metrics.KindUint64 {
+		return 0
+	}
+	return sample[0].Value.Uint64()
+}
+
+// CheckOutput は出力オブジェクトがオブジェクト数・data・全体のバイト数の上限に収まるか確認する
+func (l Limits) CheckOutput(engine, script string, objects []kube.Object) error {
+	limitErr := func(limit Limit, max, used int) *LimitError {
+		return &LimitError{Engine: engine, Script: script, Limit: limit, Max: uint64(max), Used: uint64(used)}
+	}
+
+	if l.MaxObjects > 0 && len(objects) > l.MaxObjects {
+		return limitErr(LimitObjects, l.MaxObjects, len(objects))
+	}
+
+	if l.MaxDataBytes > 0 {
+		for _, obj := range objects {
+			for _, field := range []string{"data", "binaryData"} {
+				if n := dataBytes(obj[field]); n > l.MaxDataBytes {
+					err := limitErr(LimitDataBytes, l.MaxDataBytes, n)
+					err.Object = obj.Kind() + " " + obj.Namespace() + "/" + obj.Name()
+					return err
+				}
+			}
+		}
+	}
+
+	if l.MaxOutputBytes > 0 {
+		var total int
+		for _, obj := range objects {
+			b, err := json.Marshal(obj)
+			if err != nil {
+				return fmt.Errorf("出力サイズの計算エラー: %w", err)
+			}
+			total += len(b)
+			if total > l.MaxOutputBytes {
+				return limitErr(LimitOutputBytes, l.MaxOutputBytes, total)
+			}
+		}
+	}
+	return nil
+}
+
+// dataBytes は data マップのキーと文字列値の合計バイト数
+func dataBytes(v interface{}) int {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return 0
+	}
+	var n int
+	for k, val := range m {
+		n += len(k)
+		if s, ok := val.(string); ok {
+			n += len(s)
+		}
+	}
+	return n
+}
@@ -0,0 +1,102 @@
+package scripting
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Position はスクリプト内の位置（Line・Columnは1始まり、不明なら0）
+type Position struct {
+	File   string
+	Line   int
+	Column int
+}
+
+// IsValid は行番号が分かっているかを返す
+func (p Position) IsValid() bool { return p.Line > 0 }
+
+func (p Position) String() string {
+	switch {
+	case !p.IsValid():
+		return p.File
+	case p.Column > 0:
+		return fmt.Sprintf("%s:%d:%d", p.File, p.Line, p.Column)
+	default:
+		return fmt.Sprintf("%s:%d", p.File, p.Line)
+	}
+}
+
+// LogRecord はスクリプトが出力した1件のログ
+type LogRecord struct {
+	Time    time.Time
+	Level   slog.Level
+	Message string
+	// Engine はエンジン名（"typescript", "starlark", "cue"）
+	Engine string
+	// Script はスクリプト名
+	Script string
+	// Pos はログを出力したスクリプト内の位置
+	Pos Position
+}
+
+// WithLogHandler はこの実行のスクリプトのログを送るslogのハンドラを設定する
+//
+// エンジンに設定したハンドラより優先される。
+func WithLogHandler(h slog.Handler) RunOption {
+	return func(o *RunOptions) {
+		o.LogHandler = h
+	}
+}
+
+// LogCollector はスクリプトのログを記録してslogのハンドラに送る（エンジンの実装向け）
+type LogCollector struct {
+	ctx     context.Context
+	engine  string
+	script  string
+	handler slog.Handler
+
+	mu      sync.Mutex
+	records []LogRecord
+}
+
+// NewLogCollector はLogCollectorを作成する（handlerがnilならハンドラには送らない）
+func NewLogCollector(ctx context.Context, engine, script string, handler slog.Handler) *LogCollector {
+	return &LogCollector{ctx: ctx, engine: engine, script: script, handler: handler}
+}
+
+// Log はログを1件記録する
+func (c *LogCollector) Log(level slog.Level, msg string, pos Position) {
+	rec := LogRecord{
+		Time:    time.Now(),
+		Level:   level,
+		Message: msg,
+		Engine:  c.engine,
+		Script:  c.script,
+		Pos:     pos,
+	}
+
+	c.mu.Lock()
+	c.records = append(c.records, rec)
+	c.mu.Unlock()
+
+	if c.handler == nil || !c.handler.Enabled(c.ctx, level) {
+		return
+	}
+	r := slog.NewRecord(rec.Time, level, msg, 0)
+	r.AddAttrs(slog.String("engine", rec.Engine), slog.String("script", rec.Script))
+	if pos.IsValid() {
+		r.AddAttrs(slog.String("pos", pos.String()))
+	}
+	// ハンドラのエラーでスクリプトの実行は止めない
+	_ = c.handler.Handle(c.ctx, r)
+}
+
+// Records は記録したログを返す
+func (c *LogCollector) Records() []LogRecord {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]LogRecord(nil), c.records...)
+}
@@ -0,0 +1,363 @@
+package scripting
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ParamType はパラメータの型
+type ParamType string
+
+const (
+	ParamString ParamType = "string"
+	ParamInt    ParamType = "int"
+	ParamNumber ParamType = "number"
+	ParamBool   ParamType = "bool"
+)
+
+// Param はスクリプトが宣言するパラメータ（ctx.params の1項目）
+//
+// スクリプトの先頭のコメントに1行ずつ書く（TypeScript・CUEは //、Starlarkは #）：
+//
+//	// @param groupLabel string = "vpc-id" グループ化に使うラベル
+//	// @param limit int 必須のパラメータ（= がなければ必須）
+//
+// または ParseParamsFile で読むサイドカーファイルで宣言する。
+type Param struct {
+	Name string
+	Type ParamType
+	// Default はデフォルト値（nilなら必須）
+	Default interface{}
+	// Description はパラメータの説明
+	Description string
+	// Pos は宣言の位置（ヘッダーの行・サイドカーファイルの要素）
+	Pos Position
+}
+
+// Required はパラメータの指定が必須か（デフォルト値がない）を返す
+func (p Param) Required() bool {
+	return p.Default == nil
+}
+
+// paramMarker はヘッダーでパラメータを宣言するコメントの印
+const paramMarker = "@param"
+
+// paramFrameLines はパラメータのエラーのコードフレームで前後に表示する行数
+const paramFrameLines = 2
+
+// 3つの言語で ctx.params.name と書ける名前
+var paramNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// DeclaredParams はスクリプトのパラメータの宣言を返す
+//
+// script.Params があればそれを、なければ行コメントの接頭辞 comment で
+// 書かれたヘッダーの @param を読む。両方にある場合や宣言が正しくない場合は
+// CodeParam の *Error を返す。
+func DeclaredParams(engine string, script Script, comment string) ([]Param, error) {
+	header, diags := parseParamHeader(script, comment)
+	if script.Params != nil {
+		if len(header) > 0 {
+			diags = append(diags, paramDiagnostic(engine, script, header[0].Pos,
+				"パラメータの宣言がヘッダーとサイドカーファイルの両方にあります（どちらか一方にしてください）"))
+		}
+		// デフォルト値の変換で呼び出し元のスライスを書き換えない
+		header = append([]Param{}, script.Params...)
+		seen := map[string]bool{}
+		for i := range header {
+			if err := header[i].check(seen); err != nil {
+				diags = append(diags, paramDiagnostic(engine, script, header[i].Pos, err.Error()))
+			}
+		}
+	}
+	for i := range diags {
+		diags[i].Engine = engine
+	}
+	if len(diags) > 0 {
+		return nil, &Error{Phase: PhaseCompile, Diagnostics: diags}
+	}
+	return header, nil
+}
+
+// parseParamHeader はスクリプトの先頭のコメント（空行を含む）から @param の行を読む
+func parseParamHeader(script Script, comment string) ([]Param, []Diagnostic) {
+	var (
+		params []Param
+		diags  []Diagnostic
+	)
+	seen := map[string]bool{}
+	for i, line := range strings.Split(script.Source, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+		if !strings.HasPrefix(trimmed, comment) {
+			break
+		}
+		text := strings.TrimSpace(strings.TrimPrefix(trimmed, comment))
+		rest, ok := strings.CutPrefix(text, paramMarker)
+		if !ok || (rest != "" && rest[0] != ' ' && rest[0] != '\t') {
+			continue
+		}
+
+		col := strings.Index(line, paramMarker)
+		pos := Position{File: script.Name, Line: i + 1, Column: utf8.RuneCountInString(line[:col]) + 1}
+		p, err := parseParamLine(rest)
+		if err == nil {
+			p.Pos = pos
+			err = p.check(seen)
+		}
+		if err != nil {
+			diags = append(diags, paramDiagnostic("", script, pos, err.Error()))
+			continue
+		}
+		params = append(params, p)
+	}
+	return params, diags
+}
+
+// parseParamLine は "name type [= default] [description]" を読む
+func parseParamLine(s string) (Param, error) {
+	fields := strings.Fields(s)
+	if len(fields) < 2 {
+		return Param{}, fmt.Errorf("%s name type [= default] [description] の形式で書いてください", paramMarker)
+	}
+	p := Param{Name: fields[0], Type: ParamType(fields[1])}
+
+	// 名前と型の後ろ
+	rest := strings.TrimSpace(s)
+	for _, f := range fields[:2] {
+		rest = strings.TrimSpace(strings.TrimPrefix(rest, f))
+	}
+
+	if value, ok := strings.CutPrefix(rest, "="); ok {
+		value = strings.TrimSpace(value)
+		var raw string
+		if strings.HasPrefix(value, `"`) {
+			quoted, err := strconv.QuotedPrefix(value)
+			if err != nil {
+				return Param{}, fmt.Errorf("%s のデフォルト値の文字列が閉じていません", p.Name)
+			}
+			raw, _ = strconv.Unquote(quoted)
+			rest = value[len(quoted):]
+		} else {
+			raw, rest, _ = strings.Cut(value, " ")
+			if raw == "" {
+				return Param{}, fmt.Errorf("%s のデフォルト値がありません", p.Name)
+			}
+		}
+		p.Default = raw
+	}
+	p.Description = strings.TrimSpace(rest)
+	return p, nil
+}
+
+// check は宣言の名前・型・デフォルト値を確認し、デフォルト値を型に合わせて変換する
+func (p *Param) check(seen map[string]bool) error {
+	if !paramNamePattern.MatchString(p.Name) {
+		return fmt.Errorf("パラメータ名 %q は使えません（英数字と _ のみ）", p.Name)
+	}
+	if seen[p.Name] {
+		return fmt.Errorf("パラメータ %s が重複しています", p.Name)
+	}
+	seen[p.Name] = true
+
+	switch p.Type {
+	case ParamString, ParamInt, ParamNumber, ParamBool:
+	default:
+		return fmt.Errorf("パラメータ %s の型 %q は使えません（string, int, number, bool のいずれか）", p.Name, p.Type)
+	}
+	if p.Default != nil {
+		v, err := p.convert(p.Default)
+		if err != nil {
+			return fmt.Errorf("パラメータ %s のデフォルト値: %w", p.Name, err)
+		}
+		p.Default = v
+	}
+	return nil
+}
+
+// convert は値を宣言の型に変換する（CLIから渡される文字列は型に合わせて読む）
+func (p Param) convert(v interface{}) (interface{}, error) {
+	s, isString := v.(string)
+	switch p.Type {
+	case ParamString:
+		if isString {
+			return s, nil
+		}
+	case ParamInt:
+		if isString {
+			n, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%q は int ではありません", s)
+			}
+			return n, nil
+		}
+		switch n := v.(type) {
+		case int:
+			return int64(n), nil
+		case int32:
+			return int64(n), nil
+		case int64:
+			return n, nil
+		case float64:
+			if n == math.Trunc(n) && math.Abs(n) <= 1<<53 {
+				return int64(n), nil
+			}
+		}
+	case ParamNumber:
+		if isString {
+			n, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+			if err != nil {
+				return nil, fmt.Errorf("%q は number ではありません", s)
+			}
+			return n, nil
+		}
+		switch n := v.(type) {
+		case int:
+			return float64(n), nil
+		case int32:
+			return float64(n), nil
+		case int64:
+			return float64(n), nil
+		case float32:
+			return float64(n), nil
+		case float64:
+			return n, nil
+		}
+	case ParamBool:
+		if isString {
+			b, err := strconv.ParseBool(strings.TrimSpace(s))
+			if err != nil {
+				return nil, fmt.Errorf("%q は bool ではありません", s)
+			}
+			return b, nil
+		}
+		if b, ok := v.(bool); ok {
+			return b, nil
+		}
+	}
+	return nil, fmt.Errorf("%v（%T）は %s ではありません", v, v, p.Type)
+}
+
+// ResolveParams は渡されたパラメータを宣言と照らし合わせ、型の変換とデフォルト値の補完をする
+//
+// 宣言がなければ values をそのまま返す。宣言にないパラメータ、必須のパラメータの
+// 不足、型に合わない値は CodeParam の *Error（PhaseRun）になる。
+func ResolveParams(engine string, script Script, declared []Param, values map[string]interface{}) (map[string]interface{}, error) {
+	if declared == nil {
+		return values, nil
+	}
+
+	var diags []Diagnostic
+	known := map[string]bool{}
+	names := make([]string, 0, len(declared))
+	for _, p := range declared {
+		known[p.Name] = true
+		names = append(names, p.Name)
+	}
+	var unknown []string
+	for name := range values {
+		if !known[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		msg := fmt.Sprintf("宣言されていないパラメータ %s が指定されました", name)
+		if len(names) > 0 {
+			msg += fmt.Sprintf("（%s のいずれか）", strings.Join(names, ", "))
+		} else {
+			msg += "（スクリプトはパラメータを宣言していません）"
+		}
+		diags = append(diags, paramDiagnostic(engine, script, Position{}, msg))
+	}
+
+	resolved := make(map[string]interface{}, len(declared))
+	for _, p := range declared {
+		v, ok := values[p.Name]
+		if !ok {
+			if p.Required() {
+				diags = append(diags, paramDiagnostic(engine, script, p.Pos,
+					fmt.Sprintf("必須のパラメータ %s（%s）が指定されていません", p.Name, p.Type)))
+				continue
+			}
+			resolved[p.Name] = p.Default
+			continue
+		}
+		converted, err := p.convert(v)
+		if err != nil {
+			diags = append(diags, paramDiagnostic(engine, script, p.Pos,
+				fmt.Sprintf("パラメータ %s: %v", p.Name, err)))
+			continue
+		}
+		resolved[p.Name] = converted
+	}
+
+	if len(diags) > 0 {
+		return nil, &Error{Phase: PhaseRun, Diagnostics: diags}
+	}
+	return resolved, nil
+}
+
+// ParseParamsFile はサイドカーファイル（YAML）のパラメータの宣言を読む
+//
+//	params:
+//	  - name: groupLabel
+//	    type: string
+//	    default: vpc-id
+//	    description: グループ化に使うラベル
+//
+// name はエラーの位置に使うファイル名。宣言の確認は DeclaredParams で行う。
+func ParseParamsFile(name string, data []byte) ([]Param, error) {
+	var doc struct {
+		Params []yaml.Node `yaml:"params"`
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	params := []Param{}
+	for _, node := range doc.Params {
+		var raw struct {
+			Name        string      `yaml:"name"`
+			Type        string      `yaml:"type"`
+			Default     interface{} `yaml:"default"`
+			Description string      `yaml:"description"`
+		}
+		if err := node.Decode(&raw); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", name, node.Line, err)
+		}
+		params = append(params, Param{
+			Name:        raw.Name,
+			Type:        ParamType(raw.Type),
+			Default:     raw.Default,
+			Description: raw.Description,
+			Pos:         Position{File: name, Line: node.Line, Column: node.Column},
+		})
+	}
+	return params, nil
+}
+
+func paramDiagnostic(engine string, script Script, pos Position, msg string) Diagnostic {
+	if pos.File == "" {
+		pos.File = script.Name
+	}
+	d := Diagnostic{
+		Engine:   engine,
+		Severity: SeverityError,
+		Code:     CodeParam,
+		Message:  msg,
+		Pos:      pos,
+	}
+	if pos.File == script.Name {
+		d.CodeFrame = CodeFrame(script.Source, pos, paramFrameLines)
+	}
+	return d
+}
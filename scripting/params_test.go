@@ -0,0 +1,274 @@
+package scripting
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDeclaredParams(t *testing.T) {
+	tests := []struct {
+		name    string
+		script  Script
+		comment string
+		want    []Param
+		errs    []string
+	}{
+		{
+			name: "ヘッダー",
+			script: Script{Name: "main.ts", Source: `// 説明のコメント
+// @param groupLabel string = "vpc id" グループ化に使うラベル
+// @param limit int 必須
+// @param ratio number = 0.5
+// @param dryRun bool = true
+
+export default function transform(items: unknown[]) { return items; }
+// @param ignored string
+`},
+			comment: "//",
+			want: []Param{
+				{Name: "groupLabel", Type: ParamString, Default: "vpc id", Description: "グループ化に使うラベル", Pos: Position{File: "main.ts", Line: 2, Column: 4}},
+				{Name: "limit", Type: ParamInt, Description: "必須", Pos: Position{File: "main.ts", Line: 3, Column: 4}},
+				{Name: "ratio", Type: ParamNumber, Default: 0.5, Pos: Position{File: "main.ts", Line: 4, Column: 4}},
+				{Name: "dryRun", Type: ParamBool, Default: true, Pos: Position{File: "main.ts", Line: 5, Column: 4}},
+			},
+		},
+		{
+			name:    "Starlarkのコメント",
+			script:  Script{Name: "main.star", Source: "# @param n int = 3\ndef transform(items):\n    return items\n"},
+			comment: "#",
+			want:    []Param{{Name: "n", Type: ParamInt, Default: int64(3), Pos: Position{File: "main.star", Line: 1, Column: 3}}},
+		},
+		{
+			name:    "宣言なし",
+			script:  Script{Name: "main.ts", Source: "// @parameters は対象外\nexport default function transform(items: unknown[]) { return items; }\n"},
+			comment: "//",
+		},
+		{
+			name: "サイドカーファイル",
+			script: Script{Name: "main.cue", Source: "input: _\n", Params: []Param{
+				{Name: "limit", Type: ParamInt, Default: 10, Pos: Position{File: "params.yaml", Line: 2, Column: 5}},
+			}},
+			comment: "//",
+			want:    []Param{{Name: "limit", Type: ParamInt, Default: int64(10), Pos: Position{File: "params.yaml", Line: 2, Column: 5}}},
+		},
+		{
+			name:    "形式の誤り",
+			script:  Script{Name: "main.ts", Source: "// @param limit\n"},
+			comment: "//",
+			errs:    []string{"@param name type [= default] [description] の形式で書いてください"},
+		},
+		{
+			name:    "型の誤り",
+			script:  Script{Name: "main.ts", Source: "// @param limit integer\n// @param ok bool = yes\n// @param s string = \"x\n"},
+			comment: "//",
+			errs: []string{
+				`パラメータ limit の型 "integer" は使えません（string, int, number, bool のいずれか）`,
+				`パラメータ ok のデフォルト値: "yes" は bool ではありません`,
+				"s のデフォルト値の文字列が閉じていません",
+			},
+		},
+		{
+			name:    "名前の誤りと重複",
+			script:  Script{Name: "main.ts", Source: "// @param my-label string\n// @param a int\n// @param a int\n"},
+			comment: "//",
+			errs: []string{
+				`パラメータ名 "my-label" は使えません（英数字と _ のみ）`,
+				"パラメータ a が重複しています",
+			},
+		},
+		{
+			name: "ヘッダーとサイドカーファイルの両方",
+			script: Script{Name: "main.ts", Source: "// @param a int\n", Params: []Param{
+				{Name: "b", Type: ParamInt},
+			}},
+			comment: "//",
+			errs:    []string{"パラメータの宣言がヘッダーとサイドカーファイルの両方にあります（どちらか一方にしてください）"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DeclaredParams("test", tt.script, tt.comment)
+			if tt.errs != nil {
+				assertParamErrors(t, err, PhaseCompile, tt.errs)
+				return
+			}
+			if err != nil {
+				t.Fatalf("DeclaredParams: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeclaredParamsKeepsSidecar(t *testing.T) {
+	params := []Param{{Name: "limit", Type: ParamInt, Default: "10"}}
+	if _, err := DeclaredParams("test", Script{Name: "main.ts", Params: params}, "//"); err != nil {
+		t.Fatal(err)
+	}
+	if params[0].Default != "10" {
+		t.Fatalf("呼び出し元の宣言が書き換えられました: %#v", params[0].Default)
+	}
+}
+
+func TestParseParamsFile(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want []Param
+		err  string
+	}{
+		{
+			name: "宣言",
+			data: `params:
+  - name: groupLabel
+    type: string
+    default: vpc-id
+    description: グループ化に使うラベル
+  - name: limit
+    type: int
+`,
+			want: []Param{
+				{Name: "groupLabel", Type: ParamString, Default: "vpc-id", Description: "グループ化に使うラベル", Pos: Position{File: "params.yaml", Line: 2, Column: 5}},
+				{Name: "limit", Type: ParamInt, Pos: Position{File: "params.yaml", Line: 6, Column: 5}},
+			},
+		},
+		{
+			name: "空",
+			data: "params: []\n",
+			want: []Param{},
+		},
+		{
+			name: "YAMLの誤り",
+			data: "params: [\n",
+			err:  "params.yaml: ",
+		},
+		{
+			name: "要素の型の誤り",
+			data: "params:\n  - name: [a]\n",
+			err:  "params.yaml:2: ",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseParamsFile("params.yaml", []byte(tt.data))
+			if tt.err != "" {
+				if err == nil || !strings.HasPrefix(err.Error(), tt.err) {
+					t.Fatalf("err = %v, want prefix %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseParamsFile: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveParams(t *testing.T) {
+	script := Script{Name: "main.ts", Source: "// @param label string = \"vpc-id\"\n// @param limit int\n// @param ratio number = 1\n// @param dryRun bool = false\n"}
+	declared, err := DeclaredParams("test", script, "//")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		declared []Param
+		values   map[string]interface{}
+		want     map[string]interface{}
+		errs     []string
+	}{
+		{
+			name:     "CLIの文字列を型に合わせて変換",
+			declared: declared,
+			values:   map[string]interface{}{"limit": "5", "ratio": "0.25", "dryRun": "true"},
+			want:     map[string]interface{}{"label": "vpc-id", "limit": int64(5), "ratio": 0.25, "dryRun": true},
+		},
+		{
+			name:     "JSONの数値",
+			declared: declared,
+			values:   map[string]interface{}{"limit": float64(3), "ratio": 2},
+			want:     map[string]interface{}{"label": "vpc-id", "limit": int64(3), "ratio": float64(2), "dryRun": false},
+		},
+		{
+			name:   "宣言なしはそのまま",
+			values: map[string]interface{}{"x": 1},
+			want:   map[string]interface{}{"x": 1},
+		},
+		{
+			name:     "型の誤り",
+			declared: declared,
+			values:   map[string]interface{}{"limit": "many", "ratio": 1.5, "dryRun": 1, "label": true},
+			errs: []string{
+				"パラメータ label: true（bool）は string ではありません",
+				`パラメータ limit: "many" は int ではありません`,
+				"パラメータ dryRun: 1（int）は bool ではありません",
+			},
+		},
+		{
+			name:     "小数はintにしない",
+			declared: declared,
+			values:   map[string]interface{}{"limit": 1.5},
+			errs:     []string{"パラメータ limit: 1.5（float64）は int ではありません"},
+		},
+		{
+			name:     "必須の不足と宣言にないパラメータ",
+			declared: declared,
+			values:   map[string]interface{}{"zone": "a"},
+			errs: []string{
+				"宣言されていないパラメータ zone が指定されました（label, limit, ratio, dryRun のいずれか）",
+				"必須のパラメータ limit（int）が指定されていません",
+			},
+		},
+		{
+			name:     "パラメータを宣言していない",
+			declared: []Param{},
+			values:   map[string]interface{}{"zone": "a"},
+			errs:     []string{"宣言されていないパラメータ zone が指定されました（スクリプトはパラメータを宣言していません）"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResolveParams("test", script, tt.declared, tt.values)
+			if tt.errs != nil {
+				assertParamErrors(t, err, PhaseRun, tt.errs)
+				return
+			}
+			if err != nil {
+				t.Fatalf("ResolveParams: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+// assertParamErrors は err が messages の順の CodeParam の診断を持つ *Error かを確認する
+func assertParamErrors(t *testing.T, err error, phase Phase, messages []string) {
+	t.Helper()
+	var serr *Error
+	if !errors.As(err, &serr) {
+		t.Fatalf("err = %v, want *Error", err)
+	}
+	if serr.Phase != phase {
+		t.Errorf("Phase = %v, want %v", serr.Phase, phase)
+	}
+	var got []string
+	for _, d := range serr.Diagnostics {
+		if d.Code != CodeParam || d.Engine != "test" {
+			t.Errorf("Code = %v, Engine = %q, want %v, %q", d.Code, d.Engine, CodeParam, "test")
+		}
+		got = append(got, d.Message)
+	}
+	if !reflect.DeepEqual(got, messages) {
+		t.Fatalf("messages = %q, want %q", got, messages)
+	}
+}
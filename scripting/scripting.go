@@ -0,0 +1,91 @@
+// Package scripting はGoアプリケーションに組み込むスクリプトエンジンの共通インターフェースを定義する。
+//
+// 各ランタイムの実装はサブパッケージにある：
+//
+//   - scripting/typescript: esbuild + goja
+//   - scripting/starlark:   starlark-go
+//   - scripting/cuelang:    cuelang.org/go
+package scripting
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/suinplayground/golang-embedded-scripting/kube"
+)
+
+// Engine はスクリプトをコンパイルするランタイムの共通インターフェース
+type Engine interface {
+	// Name はエンジン名を返す（"typescript", "starlark", "cue"）
+	Name() string
+
+	// Compile はスクリプトをコンパイルして実行可能なProgramを返す
+	//
+	// ctxの期限切れ・キャンセル時は *ErrTimeout を返す。
+	Compile(ctx context.Context, script Script) (Program, error)
+}
+
+// Program はコンパイル済みのスクリプト
+type Program interface {
+	// Run はKubernetesオブジェクトのリストを入力としてスクリプトを実行する
+	//
+	// 入力は kind を問わずそのまま渡され、スクリプトは任意の kind のオブジェクトを返せる。
+	// ctxの期限切れ・キャンセル時は実行を中断して *ErrTimeout を、
+	// WithLimits で設定した上限を超えたときは *LimitError を返す。
+	Run(ctx context.Context, input []kube.Object, opts ...RunOption) (*Result, error)
+}
+
+// RunOptions は Program.Run の実行ごとの設定
+type RunOptions struct {
+	// Limits はリソースの上限
+	Limits Limits
+	// LogHandler はスクリプトのログの送り先（nilならエンジンの設定に従う）
+	LogHandler slog.Handler
+	// Params はスクリプトの ctx.params に渡すパラメータ
+	Params map[string]interface{}
+	// RunID は ctx.runId（空なら実行ごとにランダムに作る）
+	RunID string
+	// Now は ctx.now（ゼロ値なら実行開始時の時刻）
+	Now time.Time
+}
+
+// RunOption は Program.Run の設定を変更する
+type RunOption func(*RunOptions)
+
+// WithLimits はリソースの上限を設定する
+func WithLimits(limits Limits) RunOption {
+	return func(o *RunOptions) {
+		o.Limits = limits
+	}
+}
+
+// NewRunOptions はRunOptionを適用した設定を返す（エンジンの実装向け）
+func NewRunOptions(opts ...RunOption) RunOptions {
+	var o RunOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// Script はエンジンに渡すスクリプトのソース
+type Script struct {
+	// Name はエラー表示に使うファイル名（例: "vpc-processor.ts"）
+	Name string
+
+	// Source はスクリプトのソースコード
+	Source string
+
+	// Params はパラメータの宣言（サイドカーファイルなど）。
+	// nilならスクリプトのヘッダーの @param を使う
+	Params []Param
+}
+
+// Result はスクリプトの実行結果
+type Result struct {
+	Objects     []kube.Object
+	Diagnostics []Diagnostic
+	// Logs はスクリプトが出力したログ（console.log, print, CUEの logs フィールド）
+	Logs []LogRecord
+}
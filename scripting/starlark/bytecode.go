@@ -0,0 +1,114 @@
+package starlark
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"sync"
+
+	"go.starlark.net/starlark"
+)
+
+// WithBytecodeCache はコンパイルしたバイトコードを dir にファイルとして保存し、次のプロセスでも使い回す
+//
+// キーはファイル名・ソース・starlark-goのバージョン（バイトコードの形式）・言語の設定の
+// ハッシュなので、どれかが変わればパースし直す。load() するモジュールにも使う。
+// 読み書きに失敗したときはキャッシュを使わずにコンパイルする。
+func WithBytecodeCache(dir string) Option {
+	return func(e *Engine) {
+		if dir != "" {
+			e.bytecode = &bytecodeCache{dir: dir}
+		}
+	}
+}
+
+// bytecodeCacheExt はバイトコードのファイルの拡張子
+const bytecodeCacheExt = ".starc"
+
+// bytecodeCache はディスクのバイトコードのキャッシュ
+type bytecodeCache struct {
+	dir string
+}
+
+// interpreterVersion はバイトコードの形式とstarlark-goのモジュールのバージョン
+//
+// CompilerVersion が同じでもバージョンが変われば読み直す（開発中のビルドは形式だけ）。
+var interpreterVersion = sync.OnceValue(func() string {
+	version := fmt.Sprintf("compiler=%d", starlark.CompilerVersion)
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, dep := range info.Deps {
+			if dep.Path != "go.starlark.net" {
+				continue
+			}
+			if dep.Replace != nil {
+				dep = dep.Replace
+			}
+			version += " module=" + dep.Version + " " + dep.Sum
+		}
+	}
+	return version
+})
+
+// compileProgram はソースをパース・名前解決してプログラムにする（キャッシュがあれば読み込む）
+func compileProgram(cache *bytecodeCache, name, source string) (*starlark.Program, error) {
+	if cache == nil {
+		_, program, err := starlark.SourceProgramOptions(fileOptions, name, source, isPredeclared)
+		return program, err
+	}
+
+	file := cache.path(name, source)
+	if data, err := os.ReadFile(file); err == nil {
+		// 壊れたファイルは読み込めなければ書き直す
+		if program, err := starlark.CompiledProgram(bytes.NewReader(data)); err == nil {
+			return program, nil
+		}
+	}
+
+	_, program, err := starlark.SourceProgramOptions(fileOptions, name, source, isPredeclared)
+	if err != nil {
+		return nil, err
+	}
+	cache.write(file, program)
+	return program, nil
+}
+
+// isPredeclared は組み込み関数以外に定義済みの名前がないことを表す
+func isPredeclared(string) bool {
+	return false
+}
+
+// path はキャッシュのファイルのパスを返す
+func (c *bytecodeCache) path(name, source string) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%+v\x00%d:%s\x00%s", interpreterVersion(), *fileOptions, len(name), name, source)
+	return filepath.Join(c.dir, hex.EncodeToString(h.Sum(nil))+bytecodeCacheExt)
+}
+
+// write はバイトコードを書き出す（他のプロセスが途中のファイルを読まないよう一時ファイルから置き換える）
+func (c *bytecodeCache) write(file string, program *starlark.Program) {
+	var buf bytes.Buffer
+	if err := program.Write(&buf); err != nil {
+		return
+	}
+	if err := os.MkdirAll(c.dir, 0o755); err != nil {
+		return
+	}
+	tmp, err := os.CreateTemp(c.dir, "*.tmp")
+	if err != nil {
+		return
+	}
+	_, err = tmp.Write(buf.Bytes())
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), file)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+}
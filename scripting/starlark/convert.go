@@ -0,0 +1,89 @@
+package starlark
+
+import (
+	"sort"
+
+	"go.starlark.net/starlark"
+)
+
+// GoのinterfaceをStarlarkの値に変換
+func goToStarlark(v interface{}) starlark.Value {
+	switch v := v.(type) {
+	case nil:
+		return starlark.None
+	case bool:
+		return starlark.Bool(v)
+	case int:
+		return starlark.MakeInt(v)
+	case int64:
+		return starlark.MakeInt64(v)
+	case float64:
+		return starlark.Float(v)
+	case string:
+		return starlark.String(v)
+	case []interface{}:
+		elems := make([]starlark.Value, len(v))
+		for i, elem := range v {
+			elems[i] = goToStarlark(elem)
+		}
+		return starlark.NewList(elems)
+	case map[string]interface{}:
+		// キーの順序を固定して、スクリプトから見た反復順を実行ごとに変えない
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		dict := starlark.NewDict(len(v))
+		for _, key := range keys {
+			dict.SetKey(starlark.String(key), goToStarlark(v[key]))
+		}
+		return dict
+	default:
+		return starlark.None
+	}
+}
+
+// Starlarkの値をGoのinterfaceに変換
+func starlarkToGo(v starlark.Value) interface{} {
+	switch v := v.(type) {
+	case starlark.NoneType:
+		return nil
+	case starlark.Bool:
+		return bool(v)
+	case starlark.Int:
+		if i, ok := v.Int64(); ok {
+			return i
+		}
+		f, _ := starlark.AsFloat(v)
+		return f
+	case starlark.Float:
+		return float64(v)
+	case starlark.String:
+		return string(v)
+	case *starlark.List:
+		result := make([]interface{}, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			result[i] = starlarkToGo(v.Index(i))
+		}
+		return result
+	case starlark.Tuple:
+		result := make([]interface{}, len(v))
+		for i, elem := range v {
+			result[i] = starlarkToGo(elem)
+		}
+		return result
+	case *starlark.Dict:
+		result := make(map[string]interface{})
+		for _, item := range v.Items() {
+			key, ok := item[0].(starlark.String)
+			if !ok {
+				continue
+			}
+			result[string(key)] = starlarkToGo(item[1])
+		}
+		return result
+	default:
+		return nil
+	}
+}
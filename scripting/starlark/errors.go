@@ -0,0 +1,124 @@
+package starlark
+
+import (
+	"errors"
+
+	"go.starlark.net/resolve"
+	"go.starlark.net/starlark"
+	"go.starlark.net/syntax"
+
+	"github.com/suinplayground/golang-embedded-scripting/scripting"
+)
+
+// コードフレームに表示する前後の行数
+const codeFrameLines = 2
+
+// position はStarlarkの位置をPositionにする（組み込み関数の位置は無効になる）
+func position(pos syntax.Position) scripting.Position {
+	if !pos.IsValid() {
+		return scripting.Position{}
+	}
+	return scripting.Position{File: pos.Filename(), Line: int(pos.Line), Column: int(pos.Col)}
+}
+
+// compileError はパース・名前解決のエラーを診断にする
+//
+// 名前解決のエラー（未定義の名前など）はすべて報告する。
+func compileError(script scripting.Script, err error) error {
+	return &scripting.Error{Phase: scripting.PhaseCompile, Diagnostics: compileDiagnostics(script, err)}
+}
+
+func compileDiagnostics(script scripting.Script, err error) []scripting.Diagnostic {
+	var syntaxErr syntax.Error
+	var resolveErrs resolve.ErrorList
+	var diagnostics []scripting.Diagnostic
+	switch {
+	case errors.As(err, &syntaxErr):
+		diagnostics = append(diagnostics, newDiagnostic(script, scripting.CodeSyntax, syntaxErr.Msg, position(syntaxErr.Pos)))
+	case errors.As(err, &resolveErrs):
+		for _, e := range resolveErrs {
+			diagnostics = append(diagnostics, newDiagnostic(script, scripting.CodeResolve, e.Msg, position(e.Pos)))
+		}
+	default:
+		diagnostics = append(diagnostics, newDiagnostic(script, scripting.CodeCompile, err.Error(), scripting.Position{}))
+	}
+	return diagnostics
+}
+
+// runtimeError は実行時のエラーをバックトレース付きの診断にする
+//
+// scripts は load() したモジュールのソース（モジュール内の位置のコードフレームに使う）。
+func runtimeError(script scripting.Script, scripts map[string]scripting.Script, err error) error {
+	return evalError(script, scripts, scripting.PhaseRun, err)
+}
+
+// moduleError はモジュールのトップレベルの実行エラーを診断にする
+//
+// load() したモジュールのエラーには読み込みチェーンを補足として付ける。
+func moduleError(script scripting.Script, scripts map[string]scripting.Script, err error) error {
+	var loadErr *loadError
+	if errors.As(err, &loadErr) {
+		return &scripting.Error{Phase: scripting.PhaseCompile, Diagnostics: loadErr.diagnostics(scripts)}
+	}
+	return evalError(script, scripts, scripting.PhaseCompile, err)
+}
+
+// entryPointError はエントリポイントがないエラーを返す
+func entryPointError(script scripting.Script, msg string, pos scripting.Position) error {
+	msg = "エントリポイントが見つかりません: " + msg + "（def transform(ctx, items) を定義してください）"
+	return &scripting.Error{
+		Phase:       scripting.PhaseCompile,
+		Diagnostics: []scripting.Diagnostic{newDiagnostic(script, scripting.CodeEntryPoint, msg, pos)},
+	}
+}
+
+// evalError はStarlarkの実行エラーをバックトレース付きの診断にする
+func evalError(script scripting.Script, scripts map[string]scripting.Script, phase scripting.Phase, err error) error {
+	var evalErr *starlark.EvalError
+	if !errors.As(err, &evalErr) {
+		return &scripting.Error{
+			Phase:       phase,
+			Diagnostics: []scripting.Diagnostic{newDiagnostic(script, scripting.CodeRuntime, err.Error(), scripting.Position{})},
+		}
+	}
+	return &scripting.Error{Phase: phase, Diagnostics: evalDiagnostics(script, scripts, evalErr)}
+}
+
+func evalDiagnostics(script scripting.Script, scripts map[string]scripting.Script, evalErr *starlark.EvalError) []scripting.Diagnostic {
+	// CallStackは外側が先頭なので、呼び出し先が先頭になるよう逆順にする
+	stack := make([]scripting.StackFrame, len(evalErr.CallStack))
+	for i := range evalErr.CallStack {
+		frame := evalErr.CallStack.At(i)
+		stack[i] = scripting.StackFrame{Function: frame.Name, Pos: position(frame.Pos)}
+	}
+
+	// 先頭のスクリプト上のフレームをエラーの位置にする
+	var pos scripting.Position
+	for _, f := range stack {
+		if f.Pos.IsValid() {
+			pos = f.Pos
+			break
+		}
+	}
+	// エラーの位置が load() したモジュールにあれば、そのソースでコードフレームを表示する
+	if m, ok := scripts[pos.File]; ok {
+		script = m
+	}
+	d := newDiagnostic(script, scripting.CodeRuntime, evalErr.Msg, pos)
+	d.Stack = stack
+	return []scripting.Diagnostic{d}
+}
+
+func newDiagnostic(script scripting.Script, code, msg string, pos scripting.Position) scripting.Diagnostic {
+	if pos.File == "" {
+		pos.File = script.Name
+	}
+	return scripting.Diagnostic{
+		Engine:    EngineName,
+		Severity:  scripting.SeverityError,
+		Code:      code,
+		Message:   msg,
+		Pos:       pos,
+		CodeFrame: scripting.CodeFrame(script.Source, pos, codeFrameLines),
+	}
+}
@@ -0,0 +1,105 @@
+package starlark
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/suinplayground/golang-embedded-scripting/scripting"
+)
+
+func TestErrorDiagnostics(t *testing.T) {
+	fsys := fstest.MapFS{"lib.star": {Data: []byte("def div(n):\n    return 1 // n\n")}}
+	pos := func(file string, line, column int) scripting.Position {
+		return scripting.Position{File: file, Line: line, Column: column}
+	}
+	// diag は比べる診断の項目
+	type diag struct {
+		Code    string
+		Message string
+		Pos     scripting.Position
+		Stack   []scripting.StackFrame
+	}
+	tests := []struct {
+		name   string
+		source string
+		phase  scripting.Phase
+		want   []diag
+		// frame は最初の診断のコードフレームのエラーの行
+		frame string
+	}{
+		{
+			name:   "未定義の名前をすべて報告する",
+			source: "def transform(ctx, items):\n    x = foo + bar\n    return baz(items)\n",
+			phase:  scripting.PhaseCompile,
+			want: []diag{
+				{Code: scripting.CodeResolve, Message: "undefined: foo", Pos: pos("main.star", 2, 9)},
+				{Code: scripting.CodeResolve, Message: "undefined: bar", Pos: pos("main.star", 2, 15)},
+				{Code: scripting.CodeResolve, Message: "undefined: baz (did you mean bar?)", Pos: pos("main.star", 3, 12)},
+			},
+			frame: "→ 2 |     x = foo + bar",
+		},
+		{
+			name:   "入れ子の呼び出しの実行時エラー",
+			source: "load(\"lib.star\", \"div\")\n\ndef helper(n):\n    return div(n)\n\ndef transform(ctx, items):\n    return [helper(len(items))]\n",
+			phase:  scripting.PhaseRun,
+			want: []diag{{
+				Code:    scripting.CodeRuntime,
+				Message: "floored division by zero",
+				Pos:     pos("lib.star", 2, 14),
+				Stack: []scripting.StackFrame{
+					{Function: "div", Pos: pos("lib.star", 2, 14)},
+					{Function: "helper", Pos: pos("main.star", 4, 15)},
+					{Function: "transform", Pos: pos("main.star", 7, 19)},
+				},
+			}},
+			// load() したモジュールのソースで表示する
+			frame: "→ 2 |     return 1 // n",
+		},
+		{
+			name:   "トップレベルの実行時エラーは組み込み関数を飛ばした位置",
+			source: "def helper():\n    fail(\"失敗\")\n\nx = helper()\n\ndef transform(ctx, items):\n    return []\n",
+			phase:  scripting.PhaseCompile,
+			want: []diag{{
+				Code:    scripting.CodeRuntime,
+				Message: "fail: 失敗",
+				Pos:     pos("main.star", 2, 9),
+				Stack: []scripting.StackFrame{
+					{Function: "fail", Pos: scripting.Position{File: "<builtin>"}},
+					{Function: "helper", Pos: pos("main.star", 2, 9)},
+					{Function: "<toplevel>", Pos: pos("main.star", 4, 11)},
+				},
+			}},
+			frame: "→ 2 |     fail(\"失敗\")",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			program, err := New(WithModules(fsys)).Compile(ctx, scripting.Script{Name: "main.star", Source: tt.source})
+			if err == nil {
+				_, err = program.Run(ctx, nil)
+			}
+			var serr *scripting.Error
+			if !errors.As(err, &serr) {
+				t.Fatalf("err = %v, want *scripting.Error", err)
+			}
+			if serr.Phase != tt.phase {
+				t.Errorf("Phase = %v, want %v", serr.Phase, tt.phase)
+			}
+			var got []diag
+			for _, d := range serr.Diagnostics {
+				got = append(got, diag{Code: d.Code, Message: d.Message, Pos: d.Pos, Stack: d.Stack})
+			}
+			if frame := serr.Diagnostics[0].CodeFrame; !strings.Contains(frame, tt.frame+"\n") {
+				t.Errorf("CodeFrame に %q がありません:\n%s", tt.frame, frame)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,266 @@
+package starlark
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+
+	"go.starlark.net/starlark"
+	"go.starlark.net/syntax"
+
+	"github.com/suinplayground/golang-embedded-scripting/scripting"
+)
+
+// WithModules は load() で読み込むモジュールのファイルシステムを設定する
+//
+// load("//lib/labels.star", "get_label") の "//" は fsys のルート、それ以外は
+// 読み込む側のモジュールのディレクトリからの相対パスになる。fsys の外（".." で
+// ルートを出るパス）は読み込めない。設定しなければ load() はエラーになる。
+//
+// ディレクトリを許可する場合は os.DirFS、埋め込む場合は embed.FS を渡す。
+func WithModules(fsys fs.FS) Option {
+	return func(e *Engine) {
+		e.modules = fsys
+	}
+}
+
+// moduleCacheSize は Engine のすべての Compile で共有する、読み込んでfreezeしたモジュールの件数の上限
+//
+// 超えたら最も長く使われていないものから追い出す。
+const moduleCacheSize = 256
+
+// module は読み込んでfreezeしたモジュール（キャッシュのキーはパス）
+type module struct {
+	globals starlark.StringDict
+	// scripts はモジュール自身と、そこから直接・間接に読み込んだモジュールのソース
+	scripts []scripting.Script
+}
+
+// unchanged はモジュールと読み込んだすべてのモジュールが fsys で同じ内容のままかを返す
+func (m *module) unchanged(fsys fs.FS) bool {
+	for _, s := range m.scripts {
+		data, err := fs.ReadFile(fsys, s.Name)
+		if err != nil || string(data) != s.Source {
+			return false
+		}
+	}
+	return true
+}
+
+// loader は1回の Compile で load() を解決する
+type loader struct {
+	fsys  fs.FS
+	cache *scripting.Cache
+	// bytecode はモジュールのバイトコードのキャッシュ（nilなら使わない）
+	bytecode *bytecodeCache
+	// chain は読み込み中のモジュールのパス（先頭がメインのスクリプト）
+	chain []string
+	// sites は chain の各モジュールを読み込んだ load() の位置
+	sites []loadSite
+	// scripts はこのプログラムが使うモジュールのソース（コードフレームの表示用）
+	scripts map[string]scripting.Script
+	// deps は chain の2番目以降の各モジュールが（直接・間接に）読み込んだモジュール
+	deps []map[string]scripting.Script
+}
+
+// loadSite は load() を呼んだ位置と読み込んだモジュール
+type loadSite struct {
+	module string
+	pos    syntax.Position
+}
+
+func newLoader(fsys fs.FS, cache *scripting.Cache, bytecode *bytecodeCache, script scripting.Script) *loader {
+	return &loader{
+		fsys:     fsys,
+		cache:    cache,
+		bytecode: bytecode,
+		chain:    []string{script.Name},
+		scripts:  map[string]scripting.Script{script.Name: script},
+	}
+}
+
+// load は starlark.Thread.Load の実装
+//
+// モジュールは呼び出し元と同じスレッドで実行するので、中断・printは
+// メインのスクリプトと同じように扱われる。
+func (l *loader) load(thread *starlark.Thread, name string) (starlark.StringDict, error) {
+	// 深さ0が load 文のあるモジュールのトップレベル
+	site := loadSite{module: name, pos: thread.CallFrame(0).Pos}
+	from := l.scripts[site.pos.Filename()]
+
+	if l.fsys == nil {
+		return nil, l.failLoad(site, from, fmt.Errorf("モジュールの読み込み元が設定されていません（load(%q) は使えません）", name))
+	}
+	p, err := resolveModule(site.pos.Filename(), name)
+	if err != nil {
+		return nil, l.failLoad(site, from, err)
+	}
+	if i := indexOf(l.chain, p); i >= 0 {
+		cycle := append(append([]string{}, l.chain[i:]...), p)
+		return nil, l.failLoad(site, from, fmt.Errorf("load が循環しています: %s", strings.Join(cycle, " → ")))
+	}
+
+	source, err := fs.ReadFile(l.fsys, p)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			err = fmt.Errorf("モジュール %s が見つかりません", p)
+		}
+		return nil, l.failLoad(site, from, err)
+	}
+	script := scripting.Script{Name: p, Source: string(source)}
+
+	// 変わったモジュールを（間接的にでも）読み込んでいれば読み込み直す
+	cached, ok := l.cache.Get(p, func(v interface{}) bool {
+		return v.(*module).unchanged(l.fsys)
+	})
+	if ok {
+		m := cached.(*module)
+		l.use(m.scripts)
+		return m.globals, nil
+	}
+	l.add(script)
+
+	program, err := compileProgram(l.bytecode, p, script.Source)
+	if err != nil {
+		return nil, l.fail(site, script, err)
+	}
+
+	// 実行中はチェーンに積んで、内側の load() で循環を見つける
+	l.chain = append(l.chain, p)
+	l.sites = append(l.sites, site)
+	l.deps = append(l.deps, map[string]scripting.Script{p: script})
+	globals, err := program.Init(thread, nil)
+	deps := l.deps[len(l.deps)-1]
+	l.chain = l.chain[:len(l.chain)-1]
+	l.sites = l.sites[:len(l.sites)-1]
+	l.deps = l.deps[:len(l.deps)-1]
+	if err != nil {
+		// 内側のモジュールのエラーはそのまま外側に返す
+		var inner *loadError
+		if errors.As(err, &inner) {
+			return nil, err
+		}
+		return nil, l.fail(site, script, err)
+	}
+	// freezeしたモジュールは複数のプログラムから並行に使える
+	globals.Freeze()
+	m := &module{globals: globals}
+	for _, s := range deps {
+		m.scripts = append(m.scripts, s)
+	}
+	l.cache.Put(p, m)
+	l.use(m.scripts)
+	return globals, nil
+}
+
+// use は読み込んだモジュールのソースを記録し、読み込んだ側のモジュールの依存に加える
+func (l *loader) use(scripts []scripting.Script) {
+	l.add(scripts...)
+	if n := len(l.deps); n > 0 {
+		for _, s := range scripts {
+			l.deps[n-1][s.Name] = s
+		}
+	}
+}
+
+func (l *loader) add(scripts ...scripting.Script) {
+	for _, s := range scripts {
+		if _, ok := l.scripts[s.Name]; !ok {
+			l.scripts[s.Name] = s
+		}
+	}
+}
+
+// fail はモジュールのコンパイル・実行のエラーを読み込みチェーン付きで返す
+func (l *loader) fail(site loadSite, script scripting.Script, err error) error {
+	sites := append(append([]loadSite{}, l.sites...), site)
+	return &loadError{sites: sites, script: script, err: err}
+}
+
+// failLoad は load 文自体のエラー（見つからない・循環など）を返す
+//
+// script は load 文のあるモジュール。
+func (l *loader) failLoad(site loadSite, script scripting.Script, err error) error {
+	e := l.fail(site, script, err).(*loadError)
+	e.atLoad = true
+	return e
+}
+
+// resolveModule は load() のモジュール名をファイルシステム内のパスにする
+func resolveModule(from, name string) (string, error) {
+	var p string
+	switch {
+	case strings.HasPrefix(name, "//"):
+		p = strings.TrimPrefix(name, "//")
+	case strings.HasPrefix(name, "/"):
+		return "", fmt.Errorf("絶対パスの load(%q) は使えません（\"//\" で始まるルートからのパスか相対パスにしてください）", name)
+	default:
+		p = path.Join(path.Dir(from), name)
+	}
+	p = path.Clean(p)
+	if !fs.ValidPath(p) || p == "." {
+		return "", fmt.Errorf("モジュール %q はルートの外を参照しています", name)
+	}
+	return p, nil
+}
+
+func indexOf(list []string, s string) int {
+	for i, v := range list {
+		if v == s {
+			return i
+		}
+	}
+	return -1
+}
+
+// loadError はモジュールの読み込みエラーと、そこまでの load() の位置
+type loadError struct {
+	// sites は外側の load() から順に並ぶ
+	sites []loadSite
+	// script はエラーの位置があるモジュール
+	script scripting.Script
+	err    error
+	// atLoad は load 文自体のエラー（位置は最後の load 文）
+	atLoad bool
+}
+
+func (e *loadError) Error() string {
+	return e.err.Error()
+}
+
+func (e *loadError) Unwrap() error {
+	return e.err
+}
+
+// diagnostics はエラーの診断に読み込みチェーンの補足を付ける
+func (e *loadError) diagnostics(scripts map[string]scripting.Script) []scripting.Diagnostic {
+	var diags []scripting.Diagnostic
+	var evalErr *starlark.EvalError
+	switch {
+	case e.atLoad:
+		site := e.sites[len(e.sites)-1]
+		diags = []scripting.Diagnostic{newDiagnostic(e.script, scripting.CodeLoad, e.err.Error(), position(site.pos))}
+	case errors.As(e.err, &evalErr):
+		diags = evalDiagnostics(e.script, scripts, evalErr)
+	default:
+		diags = compileDiagnostics(e.script, e.err)
+	}
+
+	// 内側の load() から順に（スタックトレースと同じ向き）。
+	// load 文自体のエラーは最後の load 文がエラーの位置になる
+	sites := e.sites
+	if e.atLoad {
+		sites = sites[:len(sites)-1]
+	}
+	for i := range diags {
+		for j := len(sites) - 1; j >= 0; j-- {
+			diags[i].Notes = append(diags[i].Notes, scripting.Note{
+				Message: fmt.Sprintf("load(%q) で読み込まれました", sites[j].module),
+				Pos:     position(sites[j].pos),
+			})
+		}
+	}
+	return diags
+}
@@ -0,0 +1,152 @@
+package starlark
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"testing/fstest"
+
+	"github.com/suinplayground/golang-embedded-scripting/scripting"
+)
+
+// nameScript は load() した value() を ConfigMap の名前にして返すスクリプト
+const nameScript = `load("//lib/a.star", "value")
+
+def transform(ctx, items):
+    return [{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": value()}}]
+`
+
+func compileAndRunName(t *testing.T, e *Engine, script scripting.Script) string {
+	t.Helper()
+	ctx := context.Background()
+	program, err := e.Compile(ctx, script)
+	if err != nil {
+		t.Fatalf("Compile: %v", err)
+	}
+	result, err := program.Run(ctx, nil)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(result.Objects) != 1 {
+		t.Fatalf("オブジェクトが %d 個です", len(result.Objects))
+	}
+	return result.Objects[0].Name()
+}
+
+func TestLoadReloadsChangedDependency(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+	}{
+		{name: "モジュールのキャッシュのみ"},
+		{name: "プログラムのキャッシュあり", opts: []Option{WithCache(scripting.NewCache(0))}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := fstest.MapFS{
+				"lib/a.star": {Data: []byte("load(\"b.star\", \"name\")\n\ndef value():\n    return name\n")},
+				"lib/b.star": {Data: []byte("name = \"one\"\n")},
+			}
+			e := New(append([]Option{WithModules(fsys)}, tt.opts...)...)
+			script := scripting.Script{Name: "main.star", Source: nameScript}
+
+			if got := compileAndRunName(t, e, script); got != "one" {
+				t.Fatalf("1回目: got %q, want %q", got, "one")
+			}
+			// 間接的に読み込んだモジュールだけを変更する
+			fsys["lib/b.star"] = &fstest.MapFile{Data: []byte("name = \"two\"\n")}
+			if got := compileAndRunName(t, e, script); got != "two" {
+				t.Fatalf("変更後: got %q, want %q", got, "two")
+			}
+		})
+	}
+}
+
+func TestLoadModuleCacheIsBounded(t *testing.T) {
+	fsys := fstest.MapFS{}
+	e := New(WithModules(fsys))
+	for i := 0; i < moduleCacheSize+10; i++ {
+		name := fmt.Sprintf("m%d.star", i)
+		fsys[name] = &fstest.MapFile{Data: []byte("x = 1\n")}
+		script := scripting.Script{
+			Name:   "main.star",
+			Source: "load(\"" + name + "\", \"x\")\n\ndef transform(ctx, items):\n    return []\n",
+		}
+		if _, err := e.Compile(context.Background(), script); err != nil {
+			t.Fatalf("Compile: %v", err)
+		}
+	}
+	if stats := e.cache.Stats(); stats.Entries != moduleCacheSize || stats.Evictions != 10 {
+		t.Fatalf("Entries=%d Evictions=%d, want %d, 10", stats.Entries, stats.Evictions, moduleCacheSize)
+	}
+}
+
+func TestLoadRejectsEscapes(t *testing.T) {
+	fsys := fstest.MapFS{
+		"lib/a.star":  {Data: []byte("load(\"../../secret.star\", \"x\")\n")},
+		"secret.star": {Data: []byte("x = 1\n")},
+	}
+	tests := []struct {
+		name    string
+		module  string
+		message string
+	}{
+		{name: "親ディレクトリ", module: "../secret.star", message: `モジュール "../secret.star" はルートの外を参照しています`},
+		{name: "ルートからの親ディレクトリ", module: "//../secret.star", message: `モジュール "//../secret.star" はルートの外を参照しています`},
+		{name: "途中でルートを出る", module: "lib/../../secret.star", message: `モジュール "lib/../../secret.star" はルートの外を参照しています`},
+		{name: "絶対パス", module: "/secret.star", message: `絶対パスの load("/secret.star") は使えません（"//" で始まるルートからのパスか相対パスにしてください）`},
+		{name: "読み込んだモジュールから", module: "//lib/a.star", message: `モジュール "../../secret.star" はルートの外を参照しています`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			script := scripting.Script{
+				Name:   "main.star",
+				Source: "load(\"" + tt.module + "\", \"x\")\n\ndef transform(ctx, items):\n    return []\n",
+			}
+			_, err := New(WithModules(fsys)).Compile(context.Background(), script)
+			var serr *scripting.Error
+			if !errors.As(err, &serr) {
+				t.Fatalf("err = %v, want *scripting.Error", err)
+			}
+			d := serr.Diagnostics[0]
+			if d.Code != scripting.CodeLoad {
+				t.Errorf("Code = %q, want %q", d.Code, scripting.CodeLoad)
+			}
+			if d.Message != tt.message {
+				t.Errorf("Message = %q, want %q", d.Message, tt.message)
+			}
+		})
+	}
+}
+
+func TestLoadFromNestedScript(t *testing.T) {
+	fsys := fstest.MapFS{
+		"util.star":     {Data: []byte("name = \"root\"\n")},
+		"sub/util.star": {Data: []byte("name = \"sub\"\n")},
+	}
+	tests := []struct {
+		name   string
+		script string
+		module string
+		want   string
+	}{
+		{name: "相対パスはスクリプトのディレクトリから", script: "sub/main.star", module: "util.star", want: "sub"},
+		{name: "親ディレクトリ", script: "sub/main.star", module: "../util.star", want: "root"},
+		{name: "ルートからのパス", script: "sub/main.star", module: "//util.star", want: "root"},
+		{name: "ルートのスクリプト", script: "main.star", module: "util.star", want: "root"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			script := scripting.Script{
+				Name: tt.script,
+				Source: "load(\"" + tt.module + "\", \"name\")\n\n" +
+					"def transform(ctx, items):\n" +
+					"    return [{\"apiVersion\": \"v1\", \"kind\": \"ConfigMap\", \"metadata\": {\"name\": name}}]\n",
+			}
+			if got := compileAndRunName(t, New(WithModules(fsys)), script); got != tt.want {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,343 @@
+// Package starlark はstarlark-goでスクリプトを実行するエンジン
+package starlark
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"strings"
+
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+	"go.starlark.net/syntax"
+
+	"github.com/suinplayground/golang-embedded-scripting/kube"
+	"github.com/suinplayground/golang-embedded-scripting/scripting"
+)
+
+// EngineName はこのエンジンの名前
+const EngineName = "starlark"
+
+// LineComment はヘッダーでパラメータ（@param）を宣言する行コメント
+const LineComment = "#"
+
+// Engine はStarlarkスクリプトを実行するscripting.Engineの実装
+//
+// スクリプトは def transform(ctx, items) を定義し、その戻り値を結果として返す。
+// モジュールはCompileで1回だけ読み込んでfreezeし、Runごとに transform を呼び出す。
+type Engine struct {
+	logHandler slog.Handler
+	modules    fs.FS
+	cache      *scripting.Cache
+	programs   *scripting.Cache
+	bytecode   *bytecodeCache
+	limits     scripting.Limits
+}
+
+// Option はEngineの設定を変更する
+type Option func(*Engine)
+
+// WithLogHandler はスクリプトのprintを送るslogのハンドラを設定する
+//
+// 設定しなければログは scripting.Result.Logs に記録されるだけになる。
+func WithLogHandler(h slog.Handler) Option {
+	return func(e *Engine) {
+		e.logHandler = h
+	}
+}
+
+// WithCache はコンパイル済みのプログラムをcに保存し、同じスクリプトのコンパイルで使い回す
+//
+// パース・トップレベルの実行・freezeを済ませたプログラムをキャッシュするので、
+// トップレベルの print は最初のコンパイルのときだけ記録される。
+// load() したモジュール（間接的に読み込んだものを含む）が変わっていればコンパイルし直す。
+func WithCache(c *scripting.Cache) Option {
+	return func(e *Engine) {
+		e.programs = c
+	}
+}
+
+// WithCompileLimits は Compile で実行するトップレベルと load() したモジュールの上限を設定する
+//
+// 使うのは MaxSteps と MaxAllocBytes だけで、Run の上限（scripting.WithLimits）とは別に数える。
+func WithCompileLimits(limits scripting.Limits) Option {
+	return func(e *Engine) {
+		e.limits = limits
+	}
+}
+
+// New はStarlarkエンジンを作成する
+func New(opts ...Option) *Engine {
+	e := &Engine{cache: scripting.NewCache(moduleCacheSize)}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+func (e *Engine) Name() string { return EngineName }
+
+// fileOptions はメインのスクリプトと load() するモジュールに共通の言語の設定
+var fileOptions = &syntax.FileOptions{}
+
+// entryName はエントリポイントの関数名
+const entryName = "transform"
+
+// Compile はStarlarkスクリプトをパース・解決し、モジュールを読み込んでProgramを作成する
+//
+// パースは途中で中断できないので、ctxは開始前と終了後に確認する。
+// トップレベルのprintはエンジンのログハンドラに送る。
+func (e *Engine) Compile(ctx context.Context, script scripting.Script) (scripting.Program, error) {
+	if ctx.Err() != nil {
+		return nil, scripting.NewTimeout(ctx, EngineName, script.Name, scripting.PhaseCompile)
+	}
+
+	// モジュールの読み込み元・トップレベルの実行の上限が違えばコンパイル結果も変わる
+	key := scripting.CacheKey(EngineName, script, scripting.ModulesKey(e.modules),
+		fmt.Sprint(e.limits.MaxSteps, e.limits.MaxAllocBytes))
+	if e.programs != nil {
+		cached, ok := e.programs.Get(key, func(v interface{}) bool {
+			return v.(*Program).modules.Unchanged(e.modules)
+		})
+		if ok {
+			return cached.(*Program).withLogHandler(e.logHandler), nil
+		}
+	}
+
+	params, err := scripting.DeclaredParams(EngineName, script, LineComment)
+	if err != nil {
+		return nil, err
+	}
+
+	program, err := compileProgram(e.bytecode, script.Name, script.Source)
+	if err != nil {
+		return nil, compileError(script, err)
+	}
+	if ctx.Err() != nil {
+		return nil, scripting.NewTimeout(ctx, EngineName, script.Name, scripting.PhaseCompile)
+	}
+
+	// モジュールのトップレベルを実行してグローバル変数を作る
+	logs := scripting.NewLogCollector(ctx, EngineName, script.Name, e.logHandler)
+	ctx, stopWatch := e.limits.WatchAlloc(ctx, EngineName, script.Name)
+	defer stopWatch()
+	thread := newThread(script.Name, logs)
+	setMaxSteps(thread, e.limits)
+	loader := newLoader(e.modules, e.cache, e.bytecode, script)
+	thread.Load = loader.load
+	stop := context.AfterFunc(ctx, func() {
+		thread.Cancel(context.Cause(ctx).Error())
+	})
+	defer stop()
+
+	globals, err := program.Init(thread, nil)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, scripting.Interrupted(ctx, EngineName, script.Name, scripting.PhaseCompile)
+		}
+		if err := stepsExceeded(thread, e.limits, script); err != nil {
+			return nil, err
+		}
+		return nil, moduleError(script, loader.scripts, err)
+	}
+	// freezeしたグローバル変数は複数のRunから並行に使える
+	globals.Freeze()
+
+	transform, err := entryPoint(script, globals)
+	if err != nil {
+		return nil, err
+	}
+	sources := make(map[string]string, len(loader.scripts))
+	for name, s := range loader.scripts {
+		sources[name] = s.Source
+	}
+	p := &Program{
+		script:     script,
+		scripts:    loader.scripts,
+		modules:    scripting.NewModuleDigests(script, sources),
+		params:     params,
+		logHandler: e.logHandler,
+		transform:  transform,
+	}
+	if e.programs != nil {
+		e.programs.Put(key, p)
+	}
+	return p, nil
+}
+
+// entryPoint はグローバル変数からエントリポイントの関数を取り出す
+func entryPoint(script scripting.Script, globals starlark.StringDict) (starlark.Callable, error) {
+	v, ok := globals[entryName]
+	if !ok {
+		return nil, entryPointError(script, fmt.Sprintf("関数 %s がありません", entryName), scripting.Position{})
+	}
+	fn, ok := v.(starlark.Callable)
+	if !ok {
+		return nil, entryPointError(script, fmt.Sprintf("%s が関数ではありません（%s）", entryName, v.Type()), scripting.Position{})
+	}
+	if f, ok := fn.(*starlark.Function); ok && f.NumParams() < 2 && !f.HasVarargs() {
+		return nil, entryPointError(script, fmt.Sprintf("%s は引数 (ctx, items) を受け取る必要があります", entryName), position(f.Position()))
+	}
+	return fn, nil
+}
+
+// Program はコンパイル済みのStarlarkスクリプト
+type Program struct {
+	script     scripting.Script
+	params     []scripting.Param
+	logHandler slog.Handler
+	// transform はfreezeしたモジュールのエントリポイント
+	transform starlark.Callable
+	// scripts はメインのスクリプトと load() したモジュールのソース（エラー表示用）
+	scripts map[string]scripting.Script
+	// modules は load() したモジュールの内容のハッシュ（キャッシュの確認用）
+	modules scripting.ModuleDigests
+}
+
+// withLogHandler はログの送り先だけを変えたProgramを返す（キャッシュしたProgramは共有する）
+func (p *Program) withLogHandler(h slog.Handler) *Program {
+	cp := *p
+	cp.logHandler = h
+	return &cp
+}
+
+// Run はStarlarkの新しいスレッドでスクリプトを実行する
+func (p *Program) Run(ctx context.Context, objects []kube.Object, opts ...scripting.RunOption) (*scripting.Result, error) {
+	if ctx.Err() != nil {
+		return nil, scripting.NewTimeout(ctx, EngineName, p.script.Name, scripting.PhaseRun)
+	}
+	runOpts := scripting.NewRunOptions(opts...)
+
+	// 宣言に合わせてパラメータを確認する（スクリプトを実行する前に）
+	runCtx := runOpts.Context(p.script.Name)
+	var err error
+	if runCtx.Params, err = scripting.ResolveParams(EngineName, p.script, p.params, runCtx.Params); err != nil {
+		return nil, err
+	}
+
+	limits := runOpts.Limits
+	logHandler := runOpts.LogHandler
+	if logHandler == nil {
+		logHandler = p.logHandler
+	}
+	logs := scripting.NewLogCollector(ctx, EngineName, p.script.Name, logHandler)
+
+	// メモリ割り当ての上限を超えたらctxが終了する
+	ctx, stopWatch := limits.WatchAlloc(ctx, EngineName, p.script.Name)
+	defer stopWatch()
+
+	// Starlarkスレッドを作成
+	thread := newThread(p.script.Name, logs)
+
+	setMaxSteps(thread, limits)
+
+	// ctxが終了したら実行中のスクリプトを中断する
+	stop := context.AfterFunc(ctx, func() {
+		thread.Cancel(context.Cause(ctx).Error())
+	})
+	defer stop()
+
+	// transform(ctx, items) を呼び出す
+	scriptCtx, err := newContext(runCtx, logs)
+	if err != nil {
+		return nil, err
+	}
+	items := goToStarlark(kube.Generic(objects))
+	resultValue, err := starlark.Call(thread, p.transform, starlark.Tuple{scriptCtx, items}, nil)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, scripting.Interrupted(ctx, EngineName, p.script.Name, scripting.PhaseRun)
+		}
+		if err := stepsExceeded(thread, limits, p.script); err != nil {
+			return nil, err
+		}
+		return nil, runtimeError(p.script, p.scripts, err)
+	}
+
+	// Starlarkの値をGoのオブジェクトに変換
+	outputs, err := kube.ObjectsFromGeneric(starlarkToGo(resultValue))
+	if err != nil {
+		return nil, err
+	}
+	if err := limits.CheckOutput(EngineName, p.script.Name, outputs); err != nil {
+		return nil, err
+	}
+
+	return &scripting.Result{Objects: outputs, Logs: logs.Records()}, nil
+}
+
+// setMaxSteps はスレッドに実行ステップ数の上限を設定する
+//
+// starlark-go は設定した値のステップで中断するので、上限のステップ数までは
+// 実行できるように1つ多く設定する（中断したときの使用量は上限 + 1 になる）。
+func setMaxSteps(thread *starlark.Thread, limits scripting.Limits) {
+	if limits.MaxSteps > 0 {
+		thread.SetMaxExecutionSteps(limits.MaxSteps + 1)
+	}
+}
+
+// stepsExceeded はスレッドが実行ステップ数の上限を超えていれば *scripting.LimitError を返す
+func stepsExceeded(thread *starlark.Thread, limits scripting.Limits, script scripting.Script) error {
+	if limits.MaxSteps == 0 || thread.ExecutionSteps() <= limits.MaxSteps {
+		return nil
+	}
+	return &scripting.LimitError{
+		Engine: EngineName,
+		Script: script.Name,
+		Limit:  scripting.LimitSteps,
+		Max:    limits.MaxSteps,
+		Used:   thread.ExecutionSteps(),
+	}
+}
+
+// newContext はスクリプトに渡すctx（struct）を作成する
+func newContext(c scripting.Context, logs *scripting.LogCollector) (*starlarkstruct.Struct, error) {
+	values, err := c.Generic()
+	if err != nil {
+		return nil, err
+	}
+	fields := starlark.StringDict{}
+	for k, v := range values {
+		fields[k] = goToStarlark(v)
+	}
+
+	// ctx.log.info(...) などはprintと同じく引数を空白区切りで記録する
+	logger := starlark.StringDict{}
+	for name, level := range map[string]slog.Level{
+		"debug": slog.LevelDebug,
+		"info":  slog.LevelInfo,
+		"warn":  slog.LevelWarn,
+		"error": slog.LevelError,
+	} {
+		logger[name] = starlark.NewBuiltin(name, func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+			if len(kwargs) > 0 {
+				return nil, fmt.Errorf("log.%s: キーワード引数は使えません", name)
+			}
+			parts := make([]string, len(args))
+			for i, arg := range args {
+				if s, ok := starlark.AsString(arg); ok {
+					parts[i] = s
+				} else {
+					parts[i] = arg.String()
+				}
+			}
+			logs.Log(level, strings.Join(parts, " "), position(thread.CallFrame(1).Pos))
+			return starlark.None, nil
+		})
+	}
+	fields["log"] = starlarkstruct.FromStringDict(starlarkstruct.Default, logger)
+
+	return starlarkstruct.FromStringDict(starlarkstruct.Default, fields), nil
+}
+
+// newThread はprintをlogsに記録するスレッドを作成する
+func newThread(name string, logs *scripting.LogCollector) *starlark.Thread {
+	return &starlark.Thread{
+		Name: name,
+		Print: func(thread *starlark.Thread, msg string) {
+			// 深さ0はprint自身、1が呼び出し元
+			logs.Log(slog.LevelInfo, msg, position(thread.CallFrame(1).Pos))
+		},
+	}
+}
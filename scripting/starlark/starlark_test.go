@@ -0,0 +1,155 @@
+package starlark
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/suinplayground/golang-embedded-scripting/scripting"
+)
+
+func TestCompileLimits(t *testing.T) {
+	const loop = "def loop():\n    n = []\n    for i in range(10000000):\n        n.append(str(i))\n    return n\n"
+	fsys := fstest.MapFS{"lib/loop.star": {Data: []byte(loop + "x = loop()\n")}}
+	entry := "\ndef transform(ctx, items):\n    return []\n"
+
+	tests := []struct {
+		name   string
+		source string
+		limits scripting.Limits
+		want   scripting.Limit
+		used   uint64
+	}{
+		{name: "トップレベルのステップ数", source: loop + "x = loop()\n" + entry, limits: scripting.Limits{MaxSteps: 1000}, want: scripting.LimitSteps, used: 1001},
+		{name: "load() したモジュールのステップ数", source: "load(\"//lib/loop.star\", \"x\")\n" + entry, limits: scripting.Limits{MaxSteps: 1000}, want: scripting.LimitSteps, used: 1001},
+		{name: "トップレベルの割り当て", source: loop + "x = loop()\n" + entry, limits: scripting.Limits{MaxAllocBytes: 1000}, want: scripting.LimitAlloc},
+		{name: "上限内", source: "x = 1\n" + entry, limits: scripting.Limits{MaxSteps: 1000, MaxAllocBytes: 64 << 20}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := New(WithModules(fsys), WithCompileLimits(tt.limits))
+			_, err := e.Compile(context.Background(), scripting.Script{Name: "main.star", Source: tt.source})
+			if tt.want == "" {
+				if err != nil {
+					t.Fatalf("Compile: %v", err)
+				}
+				return
+			}
+			var limitErr *scripting.LimitError
+			if !errors.As(err, &limitErr) {
+				t.Fatalf("err = %v, want *scripting.LimitError", err)
+			}
+			if limitErr.Limit != tt.want {
+				t.Fatalf("Limit = %q, want %q", limitErr.Limit, tt.want)
+			}
+			if limitErr.Used <= limitErr.Max {
+				t.Errorf("Used = %d, want > Max (%d)", limitErr.Used, limitErr.Max)
+			}
+			if tt.used != 0 && limitErr.Used != tt.used {
+				t.Errorf("Used = %d, want %d", limitErr.Used, tt.used)
+			}
+		})
+	}
+}
+
+func TestCacheSharedByEngines(t *testing.T) {
+	const loop = "def loop():\n    n = 0\n    for i in range(100000):\n        n += i\n    return n\n\nx = loop()\n"
+	script := scripting.Script{
+		Name:   "main.star",
+		Source: "load(\"//lib.star\", \"name\")\n" + loop + "\ndef transform(ctx, items):\n    return [{\"apiVersion\": \"v1\", \"kind\": \"ConfigMap\", \"metadata\": {\"name\": name}}]\n",
+	}
+	one := fstest.MapFS{"lib.star": {Data: []byte("name = \"one\"\n")}}
+	two := fstest.MapFS{"lib.star": {Data: []byte("name = \"two\"\n")}}
+
+	tests := []struct {
+		name    string
+		second  []Option
+		want    string
+		limited bool
+		hits    uint64
+	}{
+		{name: "同じ設定", second: []Option{WithModules(one)}, want: "one", hits: 1},
+		{name: "別のモジュールの読み込み元", second: []Option{WithModules(two)}, want: "two"},
+		{name: "別の上限", second: []Option{WithModules(one), WithCompileLimits(scripting.Limits{MaxSteps: 1000})}, limited: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := scripting.NewCache(0)
+			if got := compileAndRunName(t, New(WithModules(one), WithCache(cache)), script); got != "one" {
+				t.Fatalf("1つ目のEngine: got %q, want %q", got, "one")
+			}
+
+			second := New(append(tt.second, WithCache(cache))...)
+			if tt.limited {
+				var limitErr *scripting.LimitError
+				if _, err := second.Compile(context.Background(), script); !errors.As(err, &limitErr) {
+					t.Fatalf("err = %v, want *scripting.LimitError", err)
+				}
+			} else if got := compileAndRunName(t, second, script); got != tt.want {
+				t.Fatalf("2つ目のEngine: got %q, want %q", got, tt.want)
+			}
+			// 設定の違うEngineのプログラムは別のキーになる（取り出して捨てることもない）
+			if stats := cache.Stats(); stats.Hits != tt.hits || stats.Stale != 0 {
+				t.Fatalf("Hits=%d Stale=%d, want %d, 0", stats.Hits, stats.Stale, tt.hits)
+			}
+		})
+	}
+}
+
+func TestEntryPoint(t *testing.T) {
+	const hint = "（def transform(ctx, items) を定義してください）"
+	tests := []struct {
+		name    string
+		source  string
+		message string
+		pos     scripting.Position
+	}{
+		{name: "関数", source: "def transform(ctx, items):\n    return []\n"},
+		{name: "可変長引数", source: "def transform(*args):\n    return []\n"},
+		{name: "組み込み関数", source: "transform = list\n"},
+		{
+			name:    "transformがない",
+			source:  "def main(ctx, items):\n    return []\n",
+			message: "エントリポイントが見つかりません: 関数 transform がありません" + hint,
+			pos:     scripting.Position{File: "main.star"},
+		},
+		{
+			name:    "関数ではない",
+			source:  "transform = 42\n",
+			message: "エントリポイントが見つかりません: transform が関数ではありません（int）" + hint,
+			pos:     scripting.Position{File: "main.star"},
+		},
+		{
+			name:    "引数が足りない",
+			source:  "# 説明\ndef transform(items):\n    return []\n",
+			message: "エントリポイントが見つかりません: transform は引数 (ctx, items) を受け取る必要があります" + hint,
+			pos:     scripting.Position{File: "main.star", Line: 2, Column: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New().Compile(context.Background(), scripting.Script{Name: "main.star", Source: tt.source})
+			if tt.message == "" {
+				if err != nil {
+					t.Fatalf("Compile: %v", err)
+				}
+				return
+			}
+			var serr *scripting.Error
+			if !errors.As(err, &serr) {
+				t.Fatalf("err = %v, want *scripting.Error", err)
+			}
+			d := serr.Diagnostics[0]
+			if serr.Phase != scripting.PhaseCompile || d.Code != scripting.CodeEntryPoint {
+				t.Errorf("Phase, Code = %v, %q, want %v, %q", serr.Phase, d.Code, scripting.PhaseCompile, scripting.CodeEntryPoint)
+			}
+			if d.Message != tt.message {
+				t.Errorf("Message = %q, want %q", d.Message, tt.message)
+			}
+			if d.Pos != tt.pos {
+				t.Errorf("Pos = %+v, want %+v", d.Pos, tt.pos)
+			}
+		})
+	}
+}
@@ -0,0 +1,288 @@
+package typescript
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/evanw/esbuild/pkg/api"
+
+	"github.com/suinplayground/golang-embedded-scripting/scripting"
+)
+
+// WithModules は import で読み込むモジュールのファイルシステムを設定する
+//
+// 相対パスの import（"./util"）は読み込む側のファイルのディレクトリから解決する。
+// メインのスクリプトは fsys の script.Name の位置にあるものとする。fsys の外（".." で
+// ルートを出るパス）は読み込めない。設定しなければ import はエラーになる。
+//
+// ディレクトリを許可する場合は os.DirFS、埋め込む場合は embed.FS を渡す。
+func WithModules(fsys fs.FS) Option {
+	return func(e *Engine) {
+		e.modules = fsys
+	}
+}
+
+// WithPackages は fsys の node_modules から import できるパッケージを設定する
+//
+// 名前は "lodash-es" や "@scope/pkg" の形で、サブパス（"lodash-es/groupBy"）も import できる。
+func WithPackages(names ...string) Option {
+	return func(e *Engine) {
+		if e.packages == nil {
+			e.packages = map[string]bool{}
+		}
+		for _, name := range names {
+			e.packages[name] = true
+		}
+	}
+}
+
+// moduleNamespace はプラグインが解決したファイルのesbuildの名前空間
+const moduleNamespace = "embedscript"
+
+// 拡張子のないimportで試す拡張子とesbuildのローダー
+var (
+	moduleExtensions = []string{".ts", ".js", ".mjs", ".json"}
+	moduleLoaders    = map[string]api.Loader{
+		".ts":   api.LoaderTS,
+		".js":   api.LoaderJS,
+		".mjs":  api.LoaderJS,
+		".cjs":  api.LoaderJS,
+		".json": api.LoaderJSON,
+	}
+)
+
+// sourceFiles はバンドルしたファイルのソース（ファイル名 → ソース、コードフレームの表示用）
+type sourceFiles map[string]string
+
+func (s sourceFiles) codeFrame(pos scripting.Position) string {
+	return scripting.CodeFrame(s[pos.File], pos, codeFrameLines)
+}
+
+// sourceName はesbuildのファイル名（"embedscript:lib/util.ts"）を fsys 内のパスにする
+//
+// 名前空間のないファイル名はメインのスクリプトなので filename を返す。
+func sourceName(name, filename string) string {
+	if p, ok := strings.CutPrefix(name, moduleNamespace+":"); ok {
+		return p
+	}
+	return filename
+}
+
+// resolver は import を fsys のファイルに解決するesbuildのプラグイン
+//
+// esbuildには実際のファイルシステムを読ませず、すべてのimportをここで解決する。
+type resolver struct {
+	fsys     fs.FS
+	packages map[string]bool
+	// entryDir はメインのスクリプトのディレクトリ（fsys 内のパス）
+	entryDir string
+
+	// esbuildはプラグインを並行に呼ぶ
+	mu      sync.Mutex
+	sources sourceFiles
+}
+
+func (r *resolver) plugin() api.Plugin {
+	return api.Plugin{
+		Name: moduleNamespace,
+		Setup: func(build api.PluginBuild) {
+			build.OnResolve(api.OnResolveOptions{Filter: ".*"}, r.resolve)
+			build.OnLoad(api.OnLoadOptions{Filter: ".*", Namespace: moduleNamespace}, r.load)
+		},
+	}
+}
+
+func (r *resolver) resolve(args api.OnResolveArgs) (api.OnResolveResult, error) {
+	if r.fsys == nil {
+		return api.OnResolveResult{}, fmt.Errorf("import %q は使えません（モジュールの読み込み元が設定されていません）", args.Path)
+	}
+
+	// メインのスクリプト（stdin）は fsys の script.Name の位置にあるものとして扱う
+	dir := r.entryDir
+	if args.Namespace == moduleNamespace {
+		dir = path.Dir(args.Importer)
+	}
+
+	var (
+		p   string
+		err error
+	)
+	switch {
+	case strings.HasPrefix(args.Path, "./") || strings.HasPrefix(args.Path, "../"):
+		p, err = r.resolveRelative(dir, args.Path)
+	case strings.HasPrefix(args.Path, "/"):
+		err = fmt.Errorf("絶対パスの import %q は使えません（相対パスにしてください）", args.Path)
+	default:
+		p, err = r.resolvePackage(args.Path)
+	}
+	if err != nil {
+		return api.OnResolveResult{}, err
+	}
+	return api.OnResolveResult{Path: p, Namespace: moduleNamespace}, nil
+}
+
+// resolveRelative は相対パスの import を解決する
+func (r *resolver) resolveRelative(dir, spec string) (string, error) {
+	p := path.Join(dir, spec)
+	if !fs.ValidPath(p) || p == "." {
+		return "", fmt.Errorf("import %q はルートの外を参照しています", spec)
+	}
+	file, ok := r.findFile(p)
+	if !ok {
+		return "", fmt.Errorf("モジュール %s が見つかりません", p)
+	}
+	return file, nil
+}
+
+// resolvePackage は node_modules のパッケージの import を解決する（許可したパッケージのみ）
+func (r *resolver) resolvePackage(spec string) (string, error) {
+	name, sub := spec, ""
+	parts := strings.SplitN(spec, "/", 3)
+	if strings.HasPrefix(spec, "@") && len(parts) >= 2 {
+		name = parts[0] + "/" + parts[1]
+		if len(parts) == 3 {
+			sub = parts[2]
+		}
+	} else if i := strings.Index(spec, "/"); i >= 0 {
+		name, sub = spec[:i], spec[i+1:]
+	}
+	if !r.packages[name] {
+		return "", fmt.Errorf("パッケージ %s の import は許可されていません", name)
+	}
+
+	dir := path.Join("node_modules", name)
+	if !fs.ValidPath(dir) {
+		return "", fmt.Errorf("パッケージ名 %q が正しくありません", name)
+	}
+	if sub == "" {
+		sub = packageEntry(r.fsys, dir)
+	}
+	p := path.Join(dir, sub)
+	if !fs.ValidPath(p) || !strings.HasPrefix(p, dir+"/") {
+		return "", fmt.Errorf("import %q はパッケージの外を参照しています", spec)
+	}
+	file, ok := r.findFile(p)
+	if !ok {
+		return "", fmt.Errorf("パッケージ %s のファイル %s が見つかりません", name, p)
+	}
+	return file, nil
+}
+
+// packageEntry は package.json の module・main（なければ index）を返す
+func packageEntry(fsys fs.FS, dir string) string {
+	data, err := fs.ReadFile(fsys, path.Join(dir, "package.json"))
+	if err != nil {
+		return "index"
+	}
+	var pkg struct {
+		Module string `json:"module"`
+		Main   string `json:"main"`
+	}
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return "index"
+	}
+	switch {
+	case pkg.Module != "":
+		return pkg.Module
+	case pkg.Main != "":
+		return pkg.Main
+	default:
+		return "index"
+	}
+}
+
+// findFile は拡張子の補完とディレクトリの index を試してファイルを探す
+//
+// TypeScriptの慣習に合わせて "./util.js" の import は util.ts も探す。
+func (r *resolver) findFile(p string) (string, bool) {
+	candidates := []string{p}
+	if strings.HasSuffix(p, ".js") {
+		candidates = append(candidates, strings.TrimSuffix(p, ".js")+".ts")
+	}
+	for _, ext := range moduleExtensions {
+		candidates = append(candidates, p+ext)
+	}
+	for _, ext := range moduleExtensions {
+		candidates = append(candidates, path.Join(p, "index"+ext))
+	}
+	for _, c := range candidates {
+		if _, ok := moduleLoaders[path.Ext(c)]; !ok {
+			continue
+		}
+		if info, err := fs.Stat(r.fsys, c); err == nil && info.Mode().IsRegular() {
+			return c, true
+		}
+	}
+	return "", false
+}
+
+func (r *resolver) load(args api.OnLoadArgs) (api.OnLoadResult, error) {
+	data, err := fs.ReadFile(r.fsys, args.Path)
+	if err != nil {
+		return api.OnLoadResult{}, err
+	}
+	source := string(data)
+
+	r.mu.Lock()
+	r.sources[args.Path] = source
+	r.mu.Unlock()
+
+	return api.OnLoadResult{Contents: &source, Loader: moduleLoaders[path.Ext(args.Path)]}, nil
+}
+
+// bundle はスクリプトとimportしたモジュールを1つのJavaScriptにまとめる（sourcemap付き）
+//
+// esbuildのエラー・警告は位置とコードフレーム付きの診断にする。
+// エラーがあれば警告も含めて *scripting.Error を返す。
+func (e *Engine) bundle(script scripting.Script) (jsCode string, sourceMap string, sources sourceFiles, warnings []scripting.Diagnostic, err error) {
+	r := &resolver{
+		fsys:     e.modules,
+		packages: e.packages,
+		entryDir: path.Dir(path.Clean(script.Name)),
+		sources:  sourceFiles{script.Name: script.Source},
+	}
+	result := api.Build(api.BuildOptions{
+		Stdin: &api.StdinOptions{
+			Contents:   script.Source,
+			Sourcefile: script.Name,
+			Loader:     api.LoaderTS,
+		},
+		Bundle:    true,
+		Write:     false,
+		Outfile:   "out.js",
+		Sourcemap: api.SourceMapExternal,
+		Target:    api.ES2020,
+		// exportをグローバル変数に集めてエントリポイントを取り出せるようにする
+		Format:     api.FormatIIFE,
+		GlobalName: exportsName,
+		Plugins:    []api.Plugin{r.plugin()},
+		// esbuildが実際のファイルシステムのパスを使わないようにする
+		AbsWorkingDir: "/",
+	})
+
+	warnings = esbuildDiagnostics(script, r.sources, scripting.SeverityWarning, result.Warnings)
+	if len(result.Errors) > 0 {
+		return "", "", nil, nil, &scripting.Error{
+			Phase:       scripting.PhaseCompile,
+			Diagnostics: append(esbuildDiagnostics(script, r.sources, scripting.SeverityError, result.Errors), warnings...),
+		}
+	}
+
+	// sourcemapはgojaに読ませず、エラーやログの位置をGo側で変換するのに使う
+	for _, f := range result.OutputFiles {
+		if strings.HasSuffix(f.Path, ".map") {
+			sourceMap = string(f.Contents)
+		} else {
+			jsCode = string(f.Contents)
+		}
+	}
+	if jsCode == "" {
+		return "", "", nil, nil, errors.New("esbuildの出力がありません")
+	}
+	return jsCode, sourceMap, r.sources, warnings, nil
+}
@@ -0,0 +1,150 @@
+package typescript
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/suinplayground/golang-embedded-scripting/scripting"
+)
+
+// importScript は spec から読み込んだ value を ConfigMap の名前にして返すスクリプト
+func importScript(spec string) scripting.Script {
+	return scripting.Script{
+		Name: "main.ts",
+		Source: "import { value } from \"" + spec + "\";\n\n" +
+			"export default function transform(items: unknown[]) {\n" +
+			"  return [{ apiVersion: \"v1\", kind: \"ConfigMap\", metadata: { name: value } }];\n" +
+			"}\n",
+	}
+}
+
+func TestBundleImports(t *testing.T) {
+	fsys := fstest.MapFS{
+		"lib/util.ts":                      {Data: []byte("export { value } from \"./sub/name\";\n")},
+		"lib/sub/name.ts":                  {Data: []byte("export const value = \"relative\";\n")},
+		"lib/index.ts":                     {Data: []byte("export const value = \"index\";\n")},
+		"lib/js.ts":                        {Data: []byte("export const value = \"js-ext\";\n")},
+		"data.json":                        {Data: []byte("{\"value\": \"json\"}\n")},
+		"node_modules/pkg/package.json":    {Data: []byte("{\"module\": \"dist/esm.js\"}\n")},
+		"node_modules/pkg/dist/esm.js":     {Data: []byte("export const value = \"package\";\n")},
+		"node_modules/pkg/extra.js":        {Data: []byte("export const value = \"subpath\";\n")},
+		"node_modules/@scope/pkg/index.js": {Data: []byte("export const value = \"scoped\";\n")},
+	}
+	tests := []struct {
+		name string
+		spec string
+		want string
+	}{
+		{name: "相対パス", spec: "./lib/util", want: "relative"},
+		{name: "ディレクトリのindex", spec: "./lib", want: "index"},
+		{name: ".jsの拡張子でtsを読む", spec: "./lib/js.js", want: "js-ext"},
+		{name: "JSON", spec: "./data.json", want: "json"},
+		{name: "途中に..があってもルートの中", spec: "./lib/../lib/util", want: "relative"},
+		{name: "パッケージのmodule", spec: "pkg", want: "package"},
+		{name: "パッケージのサブパス", spec: "pkg/extra", want: "subpath"},
+		{name: "スコープ付きパッケージ", spec: "@scope/pkg", want: "scoped"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			e := New(WithModules(fsys), WithPackages("pkg", "@scope/pkg"))
+			program, err := e.Compile(ctx, importScript(tt.spec))
+			if err != nil {
+				t.Fatalf("Compile: %v", err)
+			}
+			result, err := program.Run(ctx, nil)
+			if err != nil {
+				t.Fatalf("Run: %v", err)
+			}
+			if got := result.Objects[0].Name(); got != tt.want {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBundleRejectsImports(t *testing.T) {
+	fsys := fstest.MapFS{
+		"lib/escape.ts":                  {Data: []byte("export { value } from \"../../secret\";\n")},
+		"lib/value.ts":                   {Data: []byte("export const value = \"ok\";\n")},
+		"secret.ts":                      {Data: []byte("export const value = \"secret\";\n")},
+		"node_modules/pkg/index.js":      {Data: []byte("export const value = \"package\";\n")},
+		"node_modules/pkg/escape.js":     {Data: []byte("export { value } from \"../../../secret\";\n")},
+		"node_modules/other/index.js":    {Data: []byte("export const value = \"not allowed\";\n")},
+		"node_modules/@scope/x/index.js": {Data: []byte("export const value = \"not allowed\";\n")},
+	}
+	tests := []struct {
+		name    string
+		fsys    fstest.MapFS
+		spec    string
+		message string
+	}{
+		{name: "親ディレクトリ", fsys: fsys, spec: "../secret", message: `import "../secret" はルートの外を参照しています`},
+		{name: "途中でルートを出る", fsys: fsys, spec: "./lib/../../secret", message: `import "./lib/../../secret" はルートの外を参照しています`},
+		{name: "読み込んだモジュールから", fsys: fsys, spec: "./lib/escape", message: `import "../../secret" はルートの外を参照しています`},
+		{name: "パッケージの中から", fsys: fsys, spec: "pkg/escape", message: `import "../../../secret" はルートの外を参照しています`},
+		{name: "絶対パス", fsys: fsys, spec: "/etc/passwd", message: `絶対パスの import "/etc/passwd" は使えません（相対パスにしてください）`},
+		{name: "許可していないパッケージ", fsys: fsys, spec: "other", message: "パッケージ other の import は許可されていません"},
+		{name: "許可していないスコープ付きパッケージ", fsys: fsys, spec: "@scope/x", message: "パッケージ @scope/x の import は許可されていません"},
+		{name: "パッケージの外のサブパス", fsys: fsys, spec: "pkg/../other", message: `import "pkg/../other" はパッケージの外を参照しています`},
+		{name: "見つからないモジュール", fsys: fsys, spec: "./lib/missing", message: "モジュール lib/missing が見つかりません"},
+		{name: "モジュールの読み込み元なし", spec: "./lib/value", message: `import "./lib/value" は使えません（モジュールの読み込み元が設定されていません）`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := []Option{WithPackages("pkg")}
+			if tt.fsys != nil {
+				opts = append(opts, WithModules(tt.fsys))
+			}
+			_, err := New(opts...).Compile(context.Background(), importScript(tt.spec))
+			var serr *scripting.Error
+			if !errors.As(err, &serr) {
+				t.Fatalf("err = %v, want *scripting.Error", err)
+			}
+			d := serr.Diagnostics[0]
+			if d.Code != scripting.CodeLoad {
+				t.Errorf("Code = %q, want %q", d.Code, scripting.CodeLoad)
+			}
+			if d.Message != tt.message {
+				t.Errorf("Message = %q, want %q", d.Message, tt.message)
+			}
+		})
+	}
+}
+
+func TestBundleImportsFromNestedEntry(t *testing.T) {
+	fsys := fstest.MapFS{
+		"util.ts":     {Data: []byte("export const value = \"root\";\n")},
+		"sub/util.ts": {Data: []byte("export const value = \"sub\";\n")},
+	}
+	tests := []struct {
+		name   string
+		script string
+		spec   string
+		want   string
+	}{
+		{name: "相対パスはスクリプトのディレクトリから", script: "sub/main.ts", spec: "./util", want: "sub"},
+		{name: "親ディレクトリ", script: "sub/main.ts", spec: "../util", want: "root"},
+		{name: "ルートのスクリプト", script: "main.ts", spec: "./util", want: "root"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			script := importScript(tt.spec)
+			script.Name = tt.script
+			program, err := New(WithModules(fsys)).Compile(ctx, script)
+			if err != nil {
+				t.Fatalf("Compile: %v", err)
+			}
+			result, err := program.Run(ctx, nil)
+			if err != nil {
+				t.Fatalf("Run: %v", err)
+			}
+			if got := result.Objects[0].Name(); got != tt.want {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
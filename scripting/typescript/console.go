@@ -0,0 +1,438 @@
+package typescript
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/dop251/goja"
+
+	"github.com/suinplayground/golang-embedded-scripting/scripting"
+)
+
+// console はスクリプトに渡すconsoleオブジェクトの実装
+//
+// 出力はすべて LogCollector に送り、メソッドごとにレベルを決める：
+//
+//	debug, trace             → DEBUG
+//	log, info, table, dir,
+//	time*, count, group      → INFO
+//	warn                     → WARN
+//	error, assert（失敗時）   → ERROR
+type console struct {
+	vm    *goja.Runtime
+	logs  *scripting.LogCollector
+	stack func() []scripting.StackFrame
+
+	stringify goja.Callable
+	timers    map[string]time.Time
+	counts    map[string]int
+	indent    int
+}
+
+// newConsole はconsoleを作成する
+//
+// stack は呼び出し時点のスタックトレース（TypeScriptの位置）を返す。
+func newConsole(vm *goja.Runtime, logs *scripting.LogCollector, stack func() []scripting.StackFrame) *console {
+	c := &console{
+		vm:     vm,
+		logs:   logs,
+		stack:  stack,
+		timers: map[string]time.Time{},
+		counts: map[string]int{},
+	}
+	c.stringify, _ = goja.AssertFunction(vm.Get("JSON").ToObject(vm).Get("stringify"))
+	return c
+}
+
+// object はスクリプトのグローバル変数 console にするオブジェクトを返す
+func (c *console) object() *goja.Object {
+	obj := c.vm.NewObject()
+	methods := map[string]func(goja.FunctionCall) goja.Value{
+		"log":        c.level(slog.LevelInfo),
+		"info":       c.level(slog.LevelInfo),
+		"debug":      c.level(slog.LevelDebug),
+		"warn":       c.level(slog.LevelWarn),
+		"error":      c.level(slog.LevelError),
+		"trace":      c.trace,
+		"dir":        c.dir,
+		"table":      c.table,
+		"assert":     c.assert,
+		"time":       c.time,
+		"timeLog":    c.timeLog,
+		"timeEnd":    c.timeEnd,
+		"count":      c.count,
+		"countReset": c.countReset,
+		"group":      c.group,
+		"groupEnd":   c.groupEnd,
+	}
+	for name, fn := range methods {
+		obj.Set(name, fn)
+	}
+	obj.Set("groupCollapsed", methods["group"])
+	return obj
+}
+
+// logger はスクリプトの ctx.log にするオブジェクト（debug, info, warn, error）を返す
+func (c *console) logger() *goja.Object {
+	obj := c.vm.NewObject()
+	obj.Set("debug", c.level(slog.LevelDebug))
+	obj.Set("info", c.level(slog.LevelInfo))
+	obj.Set("warn", c.level(slog.LevelWarn))
+	obj.Set("error", c.level(slog.LevelError))
+	return obj
+}
+
+func (c *console) emit(level slog.Level, msg string) {
+	if c.indent > 0 {
+		prefix := strings.Repeat("  ", c.indent)
+		msg = prefix + strings.ReplaceAll(msg, "\n", "\n"+prefix)
+	}
+	c.logs.Log(level, msg, c.position())
+}
+
+// position はconsoleのメソッドを呼び出したスクリプトの位置
+func (c *console) position() scripting.Position {
+	for _, f := range c.stack() {
+		if f.Pos.IsValid() {
+			return f.Pos
+		}
+	}
+	return scripting.Position{}
+}
+
+func (c *console) level(level slog.Level) func(goja.FunctionCall) goja.Value {
+	return func(call goja.FunctionCall) goja.Value {
+		c.emit(level, c.format(call.Arguments))
+		return goja.Undefined()
+	}
+}
+
+// trace はメッセージにスタックトレースを付けて出力する
+func (c *console) trace(call goja.FunctionCall) goja.Value {
+	msg := "Trace"
+	if len(call.Arguments) > 0 {
+		msg += ": " + c.format(call.Arguments)
+	}
+	var b strings.Builder
+	b.WriteString(msg)
+	for _, frame := range c.stack() {
+		if frame.Pos.IsValid() {
+			fmt.Fprintf(&b, "\n    at %s", frame)
+		}
+	}
+	c.emit(slog.LevelDebug, b.String())
+	return goja.Undefined()
+}
+
+func (c *console) dir(call goja.FunctionCall) goja.Value {
+	c.emit(slog.LevelInfo, c.inspect(call.Argument(0)))
+	return goja.Undefined()
+}
+
+// assert は第1引数が偽のときだけエラーを出力する
+func (c *console) assert(call goja.FunctionCall) goja.Value {
+	if call.Argument(0).ToBoolean() {
+		return goja.Undefined()
+	}
+	msg := "Assertion failed"
+	if len(call.Arguments) > 1 {
+		msg += ": " + c.format(call.Arguments[1:])
+	}
+	c.emit(slog.LevelError, msg)
+	return goja.Undefined()
+}
+
+func (c *console) time(call goja.FunctionCall) goja.Value {
+	label := c.label(call)
+	if _, ok := c.timers[label]; ok {
+		c.emit(slog.LevelWarn, fmt.Sprintf("Timer '%s' already exists", label))
+		return goja.Undefined()
+	}
+	c.timers[label] = time.Now()
+	return goja.Undefined()
+}
+
+func (c *console) timeLog(call goja.FunctionCall) goja.Value {
+	c.elapsed(call, false)
+	return goja.Undefined()
+}
+
+func (c *console) timeEnd(call goja.FunctionCall) goja.Value {
+	c.elapsed(call, true)
+	return goja.Undefined()
+}
+
+func (c *console) elapsed(call goja.FunctionCall, end bool) {
+	label := c.label(call)
+	start, ok := c.timers[label]
+	if !ok {
+		c.emit(slog.LevelWarn, fmt.Sprintf("Timer '%s' does not exist", label))
+		return
+	}
+	if end {
+		delete(c.timers, label)
+	}
+
+	msg := fmt.Sprintf("%s: %s", label, formatElapsed(time.Since(start)))
+	if !end && len(call.Arguments) > 1 {
+		msg += " " + c.format(call.Arguments[1:])
+	}
+	c.emit(slog.LevelInfo, msg)
+}
+
+func (c *console) count(call goja.FunctionCall) goja.Value {
+	label := c.label(call)
+	c.counts[label]++
+	c.emit(slog.LevelInfo, fmt.Sprintf("%s: %d", label, c.counts[label]))
+	return goja.Undefined()
+}
+
+func (c *console) countReset(call goja.FunctionCall) goja.Value {
+	delete(c.counts, c.label(call))
+	return goja.Undefined()
+}
+
+func (c *console) group(call goja.FunctionCall) goja.Value {
+	if len(call.Arguments) > 0 {
+		c.emit(slog.LevelInfo, c.format(call.Arguments))
+	}
+	c.indent++
+	return goja.Undefined()
+}
+
+func (c *console) groupEnd(goja.FunctionCall) goja.Value {
+	if c.indent > 0 {
+		c.indent--
+	}
+	return goja.Undefined()
+}
+
+// label はtime・countのラベル（省略時は "default"）
+func (c *console) label(call goja.FunctionCall) string {
+	if arg := call.Argument(0); !goja.IsUndefined(arg) {
+		return arg.String()
+	}
+	return "default"
+}
+
+// format は引数を書式指定子（%s, %d, %i, %f, %o, %O, %j, %c, %%）を解釈して連結する
+func (c *console) format(args []goja.Value) string {
+	if len(args) == 0 {
+		return ""
+	}
+
+	var parts []string
+	rest := args
+	if first, ok := args[0].Export().(string); ok && strings.Contains(first, "%") {
+		var b strings.Builder
+		rest = args[1:]
+		for i := 0; i < len(first); i++ {
+			if first[i] != '%' || i+1 == len(first) {
+				b.WriteByte(first[i])
+				continue
+			}
+			verb := first[i+1]
+			if verb == '%' {
+				b.WriteByte('%')
+				i++
+				continue
+			}
+			if !strings.ContainsRune("sdifoOjc", rune(verb)) || len(rest) == 0 {
+				b.WriteByte('%')
+				continue
+			}
+			b.WriteString(c.formatVerb(verb, rest[0]))
+			rest = rest[1:]
+			i++
+		}
+		parts = append(parts, b.String())
+	}
+
+	for _, arg := range rest {
+		parts = append(parts, c.display(arg))
+	}
+	return strings.Join(parts, " ")
+}
+
+func (c *console) formatVerb(verb byte, v goja.Value) string {
+	switch verb {
+	case 's':
+		return c.display(v)
+	case 'd', 'i':
+		f := v.ToFloat()
+		if f != f { // NaN
+			return "NaN"
+		}
+		return strconv.FormatInt(int64(f), 10)
+	case 'f':
+		return strconv.FormatFloat(v.ToFloat(), 'f', -1, 64)
+	case 'c':
+		// CSSの指定は無視する
+		return ""
+	default: // o, O, j
+		return c.inspect(v)
+	}
+}
+
+// display は値を表示用の文字列にする（文字列はそのまま、オブジェクトはJSON）
+func (c *console) display(v goja.Value) string {
+	if v == nil {
+		return "undefined"
+	}
+	if _, ok := v.Export().(string); ok {
+		return v.String()
+	}
+	return c.inspect(v)
+}
+
+// inspect は値をJSONで表す（文字列は引用符付き）
+//
+// JSONにできない値（関数・undefined・循環参照）とErrorは別の表現にする。
+func (c *console) inspect(v goja.Value) string {
+	if v == nil || goja.IsUndefined(v) {
+		return "undefined"
+	}
+	if goja.IsNull(v) {
+		return "null"
+	}
+
+	if obj, ok := v.(*goja.Object); ok {
+		if _, isFunc := goja.AssertFunction(obj); isFunc {
+			name := obj.Get("name")
+			if name == nil || name.String() == "" {
+				return "[Function (anonymous)]"
+			}
+			return fmt.Sprintf("[Function: %s]", name)
+		}
+		if obj.ClassName() == "Error" {
+			if stack := obj.Get("stack"); stack != nil && !goja.IsUndefined(stack) {
+				return strings.TrimRight(stack.String(), "\n")
+			}
+			return obj.String()
+		}
+	}
+
+	if c.stringify != nil {
+		s, err := c.stringify(goja.Undefined(), v)
+		if err == nil && !goja.IsUndefined(s) {
+			return s.String()
+		}
+		if err != nil {
+			// 循環参照など
+			return v.String()
+		}
+	}
+	return v.String()
+}
+
+// table は配列・オブジェクトを表にして出力する
+func (c *console) table(call goja.FunctionCall) goja.Value {
+	data, ok := call.Argument(0).(*goja.Object)
+	if !ok {
+		return c.level(slog.LevelInfo)(call)
+	}
+
+	var (
+		rowKeys []string
+		columns []string
+		seen    = map[string]bool{}
+		cells   = map[string]map[string]string{}
+		values  = map[string]string{}
+	)
+	const valuesColumn = "Values"
+
+	for _, rowKey := range data.Keys() {
+		rowKeys = append(rowKeys, rowKey)
+		row := data.Get(rowKey)
+		rowObj, isObj := row.(*goja.Object)
+		if _, isFunc := goja.AssertFunction(row); !isObj || isFunc {
+			values[rowKey] = c.inspect(row)
+			continue
+		}
+		cells[rowKey] = map[string]string{}
+		for _, col := range rowObj.Keys() {
+			if !seen[col] {
+				seen[col] = true
+				columns = append(columns, col)
+			}
+			cells[rowKey][col] = c.inspect(rowObj.Get(col))
+		}
+	}
+
+	// 第2引数で列を絞り込む
+	if filter, ok := call.Argument(1).Export().([]interface{}); ok {
+		columns = columns[:0]
+		for _, col := range filter {
+			columns = append(columns, fmt.Sprint(col))
+		}
+	}
+
+	header := append([]string{"(index)"}, columns...)
+	if len(values) > 0 {
+		header = append(header, valuesColumn)
+	}
+	rows := make([][]string, len(rowKeys))
+	for i, rowKey := range rowKeys {
+		row := []string{rowKey}
+		for _, col := range columns {
+			row = append(row, cells[rowKey][col])
+		}
+		if len(values) > 0 {
+			row = append(row, values[rowKey])
+		}
+		rows[i] = row
+	}
+
+	c.emit(slog.LevelInfo, renderTable(header, rows))
+	return goja.Undefined()
+}
+
+// renderTable は罫線で囲んだ表を作る
+func renderTable(header []string, rows [][]string) string {
+	widths := make([]int, len(header))
+	for i, h := range header {
+		widths[i] = utf8.RuneCountInString(h)
+	}
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], utf8.RuneCountInString(cell))
+		}
+	}
+
+	line := func(left, mid, right string) string {
+		parts := make([]string, len(widths))
+		for i, w := range widths {
+			parts[i] = strings.Repeat("─", w+2)
+		}
+		return left + strings.Join(parts, mid) + right
+	}
+	row := func(cells []string) string {
+		parts := make([]string, len(widths))
+		for i, w := range widths {
+			cell := cells[i]
+			pad := w - utf8.RuneCountInString(cell)
+			parts[i] = " " + cell + strings.Repeat(" ", pad) + " "
+		}
+		return "│" + strings.Join(parts, "│") + "│"
+	}
+
+	lines := []string{line("┌", "┬", "┐"), row(header), line("├", "┼", "┤")}
+	for _, r := range rows {
+		lines = append(lines, row(r))
+	}
+	lines = append(lines, line("└", "┴", "┘"))
+	return strings.Join(lines, "\n")
+}
+
+// formatElapsed はconsole.timeEndの経過時間を "1.234ms" の形にする
+func formatElapsed(d time.Duration) string {
+	ms := float64(d) / float64(time.Millisecond)
+	if ms < 1000 {
+		return strconv.FormatFloat(ms, 'f', 3, 64) + "ms"
+	}
+	return strconv.FormatFloat(d.Seconds(), 'f', 3, 64) + "s"
+}
@@ -0,0 +1,74 @@
+package typescript
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/suinplayground/golang-embedded-scripting/scripting"
+)
+
+func TestConsole(t *testing.T) {
+	tests := []struct {
+		name    string
+		call    string
+		level   slog.Level
+		message string
+		// column は確認するログの位置の列（0なら確認しない）
+		column int
+	}{
+		{name: "log", call: `console.log("a", 1, true)`, level: slog.LevelInfo, message: "a 1 true"},
+		{name: "位置は呼び出したメソッド", call: `const s = "😀"; console.log(s)`, level: slog.LevelInfo, message: "😀", column: 26},
+		{name: "info", call: `console.info("info")`, level: slog.LevelInfo, message: "info"},
+		{name: "debug", call: `console.debug("debug")`, level: slog.LevelDebug, message: "debug"},
+		{name: "warn", call: `console.warn("warn")`, level: slog.LevelWarn, message: "warn"},
+		{name: "error", call: `console.error("error")`, level: slog.LevelError, message: "error"},
+		{name: "ctx.log", call: `ctx.log.warn("件数", items.length)`, level: slog.LevelWarn, message: "件数 0"},
+		{name: "オブジェクトはJSON", call: `console.log("obj", { a: [1, "x"], b: null })`, level: slog.LevelInfo, message: `obj {"a":[1,"x"],"b":null}`},
+		{name: "undefinedと関数", call: `console.log(undefined, function named() {}, () => 1)`, level: slog.LevelInfo, message: "undefined [Function: named] [Function (anonymous)]"},
+		{name: "書式指定子", call: `console.log("%s=%d (%f) %o %% %c", "n", 3.7, 0.5, { k: "v" }, "color: red", "rest")`, level: slog.LevelInfo, message: `n=3 (0.5) {"k":"v"} %  rest`},
+		{name: "足りない書式指定子", call: `console.log("%s and %s", "one")`, level: slog.LevelInfo, message: "one and %s"},
+		{name: "循環参照", call: `const o: any = {}; o.self = o; console.log(o)`, level: slog.LevelInfo, message: "[object Object]"},
+		{name: "assertの失敗", call: `console.assert(false, "条件", 1)`, level: slog.LevelError, message: "Assertion failed: 条件 1"},
+		{name: "count", call: `console.count(); console.count()`, level: slog.LevelInfo, message: "default: 2"},
+		{name: "group", call: `console.group("g"); console.log("a\nb"); console.groupEnd()`, level: slog.LevelInfo, message: "  a\n  b"},
+		{name: "table", call: `console.table([{ a: 1 }, { b: "x" }])`, level: slog.LevelInfo, message: "" +
+			"┌─────────┬───┬─────┐\n" +
+			"│ (index) │ a │ b   │\n" +
+			"├─────────┼───┼─────┤\n" +
+			"│ 0       │ 1 │     │\n" +
+			"│ 1       │   │ \"x\" │\n" +
+			"└─────────┴───┴─────┘"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			source := "export default function transform(items: unknown[], ctx: any) {\n  " + tt.call + ";\n  return [];\n}\n"
+			program, err := New().Compile(ctx, scripting.Script{Name: "main.ts", Source: source})
+			if err != nil {
+				t.Fatalf("Compile: %v", err)
+			}
+			result, err := program.Run(ctx, nil)
+			if err != nil {
+				t.Fatalf("Run: %v", err)
+			}
+			if len(result.Logs) == 0 {
+				t.Fatal("ログがありません")
+			}
+			// 最後のログ（count・group は最後の呼び出し）を確認する
+			rec := result.Logs[len(result.Logs)-1]
+			if rec.Level != tt.level {
+				t.Errorf("Level = %v, want %v", rec.Level, tt.level)
+			}
+			if rec.Message != tt.message {
+				t.Errorf("Message = %q, want %q", rec.Message, tt.message)
+			}
+			if rec.Engine != EngineName || rec.Script != "main.ts" {
+				t.Errorf("Engine, Script = %q, %q", rec.Engine, rec.Script)
+			}
+			if rec.Pos.File != "main.ts" || rec.Pos.Line != 2 || (tt.column != 0 && rec.Pos.Column != tt.column) {
+				t.Errorf("Pos = %+v, want main.ts:2:%d", rec.Pos, tt.column)
+			}
+		})
+	}
+}
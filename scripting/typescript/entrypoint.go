@@ -0,0 +1,65 @@
+package typescript
+
+import (
+	"fmt"
+
+	"github.com/dop251/goja"
+
+	"github.com/suinplayground/golang-embedded-scripting/scripting"
+)
+
+// exportsName はスクリプトのexportを集めるグローバル変数の名前
+const exportsName = "__embedscriptExports"
+
+// entryName は名前付きexportで使うエントリポイントの名前
+const entryName = "transform"
+
+// entryPoint はスクリプトのexportからエントリポイントの関数を取り出す
+//
+// export default を優先し、なければ export function transform を使う。
+func (p *Program) entryPoint(vm *goja.Runtime) (goja.Callable, error) {
+	exports := vm.Get(exportsName)
+	if exports == nil || goja.IsUndefined(exports) || goja.IsNull(exports) {
+		return nil, p.entryPointError("export がありません")
+	}
+	obj := exports.ToObject(vm)
+
+	for _, name := range []string{"default", entryName} {
+		v := obj.Get(name)
+		if v == nil || goja.IsUndefined(v) {
+			continue
+		}
+		fn, ok := goja.AssertFunction(v)
+		if !ok {
+			return nil, p.entryPointError(fmt.Sprintf("export %s が関数ではありません", name))
+		}
+		return fn, nil
+	}
+	return nil, p.entryPointError(fmt.Sprintf("export default または export function %s がありません", entryName))
+}
+
+func (p *Program) entryPointError(msg string) error {
+	return p.scriptError(scripting.CodeEntryPoint,
+		"エントリポイントが見つかりません: "+msg+"（export default function transform(items, ctx) を定義してください）")
+}
+
+// settle はエントリポイントの戻り値がPromiseなら解決した値を返す（async functionに対応する）
+//
+// gojaは関数呼び出しの後にPromiseのジョブを実行するので、
+// 外部のI/Oを待たないPromiseはこの時点で解決している。
+// rejectされたErrorは stack プロパティから位置とスタックトレースを求める。
+func (p *Program) settle(v goja.Value) (goja.Value, error) {
+	promise, ok := v.Export().(*goja.Promise)
+	if !ok {
+		return v, nil
+	}
+	switch promise.State() {
+	case goja.PromiseStateFulfilled:
+		return promise.Result(), nil
+	case goja.PromiseStateRejected:
+		reason := promise.Result()
+		return nil, p.stackError("Uncaught (in promise) "+valueMessage(reason), p.errorStack(reason))
+	default:
+		return nil, p.scriptError(scripting.CodeRuntime, "transform が返したPromiseが解決されませんでした")
+	}
+}
@@ -0,0 +1,144 @@
+package typescript
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/suinplayground/golang-embedded-scripting/scripting"
+)
+
+func TestRunErrorPosition(t *testing.T) {
+	const helper = `// 日本語のコメント
+async function helper(n: number): Promise<number> {
+  await Promise.resolve();
+  throw new Error("失敗 " + n);
+}
+`
+	pos := func(line, column int) scripting.Position {
+		return scripting.Position{File: "main.ts", Line: line, Column: column}
+	}
+	tests := []struct {
+		name    string
+		source  string
+		message string
+		pos     scripting.Position
+		stack   []scripting.StackFrame
+	}{
+		{
+			name:    "同期のthrow",
+			source:  "export default function transform(items: unknown[]) {\n  throw new Error(\"x\");\n}\n",
+			message: "Error: x",
+			pos:     pos(2, 9),
+			stack:   []scripting.StackFrame{{Function: "transform", Pos: pos(2, 9)}},
+		},
+		{
+			name:    "await の後のthrow",
+			source:  helper + "\nexport default async function transform(items: unknown[]) {\n  await helper(1);\n  return items;\n}\n",
+			message: "Uncaught (in promise) Error: 失敗 1",
+			pos:     pos(4, 9),
+			stack:   []scripting.StackFrame{{Function: "helper", Pos: pos(4, 9)}, {Function: "transform", Pos: pos(8, 9)}},
+		},
+		{
+			name:    "ネイティブ関数のreject",
+			source:  "export default async function transform(items: unknown[]) {\n  await null;\n  return JSON.parse(\"{\");\n}\n",
+			message: "Uncaught (in promise) SyntaxError: Unexpected end of JSON input (EOF)",
+			pos:     pos(3, 15),
+			stack:   []scripting.StackFrame{{Function: "parse"}, {Function: "transform", Pos: pos(3, 15)}},
+		},
+		{
+			name:    "サロゲートペアの後の列は文字単位",
+			source:  "export default function transform(items: unknown[]) {\n  const s = \"😀😀\"; throw new Error(s);\n}\n",
+			message: "Error: 😀😀",
+			pos:     pos(2, 25),
+			stack:   []scripting.StackFrame{{Function: "transform", Pos: pos(2, 25)}},
+		},
+		{
+			name:    "Error以外のreject",
+			source:  "export default async function transform(items: unknown[]) {\n  await null;\n  throw \"x\";\n}\n",
+			message: `Uncaught (in promise) "x"`,
+			pos:     scripting.Position{File: "main.ts"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			program, err := New().Compile(ctx, scripting.Script{Name: "main.ts", Source: tt.source})
+			if err != nil {
+				t.Fatalf("Compile: %v", err)
+			}
+			_, err = program.Run(ctx, nil)
+			var serr *scripting.Error
+			if !errors.As(err, &serr) {
+				t.Fatalf("err = %v, want *scripting.Error", err)
+			}
+			d := serr.Diagnostics[0]
+			if d.Message != tt.message {
+				t.Errorf("Message = %q, want %q", d.Message, tt.message)
+			}
+			if d.Pos != tt.pos {
+				t.Errorf("Pos = %+v, want %+v", d.Pos, tt.pos)
+			}
+			if !reflect.DeepEqual(d.Stack, tt.stack) {
+				t.Errorf("Stack = %+v, want %+v", d.Stack, tt.stack)
+			}
+			if tt.pos.Line > 0 && d.CodeFrame == "" {
+				t.Errorf("CodeFrame がありません")
+			}
+		})
+	}
+}
+
+func TestEntryPoint(t *testing.T) {
+	const body = "(items: unknown[]) {\n  return [{ apiVersion: \"v1\", kind: \"ConfigMap\", metadata: { name: NAME } }];\n}\n"
+	const hint = "（export default function transform(items, ctx) を定義してください）"
+	tests := []struct {
+		name    string
+		source  string
+		want    string
+		message string
+	}{
+		{name: "export default", source: "export default function transform" + strings.ReplaceAll(body, "NAME", `"default"`), want: "default"},
+		{name: "名前付きexport", source: "export function transform" + strings.ReplaceAll(body, "NAME", `"named"`), want: "named"},
+		{name: "async", source: "export default async function transform" + strings.ReplaceAll(body, "NAME", `"async"`), want: "async"},
+		{
+			name: "export default を優先する",
+			source: "export default function main" + strings.ReplaceAll(body, "NAME", `"default"`) +
+				"export function transform" + strings.ReplaceAll(body, "NAME", `"named"`),
+			want: "default",
+		},
+		{name: "exportなし", source: "function transform" + strings.ReplaceAll(body, "NAME", `"x"`), message: "エントリポイントが見つかりません: export がありません" + hint},
+		{name: "transform以外のexport", source: "export function main" + strings.ReplaceAll(body, "NAME", `"x"`), message: "エントリポイントが見つかりません: export default または export function transform がありません" + hint},
+		{name: "export default が関数ではない", source: "export default { transform: 1 };\n", message: "エントリポイントが見つかりません: export default が関数ではありません" + hint},
+		{name: "transform が関数ではない", source: "export const transform = 42;\n", message: "エントリポイントが見つかりません: export transform が関数ではありません" + hint},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			program, err := New().Compile(ctx, scripting.Script{Name: "main.ts", Source: tt.source})
+			if err != nil {
+				t.Fatalf("Compile: %v", err)
+			}
+			result, err := program.Run(ctx, nil)
+			if tt.message == "" {
+				if err != nil {
+					t.Fatalf("Run: %v", err)
+				}
+				if got := result.Objects[0].Name(); got != tt.want {
+					t.Fatalf("got %q, want %q", got, tt.want)
+				}
+				return
+			}
+			var serr *scripting.Error
+			if !errors.As(err, &serr) {
+				t.Fatalf("err = %v, want *scripting.Error", err)
+			}
+			d := serr.Diagnostics[0]
+			if d.Code != scripting.CodeEntryPoint || d.Message != tt.message {
+				t.Fatalf("Code, Message = %q, %q, want %q, %q", d.Code, d.Message, scripting.CodeEntryPoint, tt.message)
+			}
+		})
+	}
+}
@@ -0,0 +1,298 @@
+package typescript
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/dop251/goja"
+	"github.com/dop251/goja/file"
+	"github.com/evanw/esbuild/pkg/api"
+	"github.com/go-sourcemap/sourcemap"
+
+	"github.com/suinplayground/golang-embedded-scripting/scripting"
+)
+
+// コードフレームに表示する前後の行数
+const codeFrameLines = 2
+
+// tsPosition はJavaScriptの位置をsourcemapでTypeScriptの位置に変換する
+func (p *Program) tsPosition(pos file.Position) scripting.Position {
+	return tsPosition(p.smap, p.sources, p.script.Name, pos)
+}
+
+// tsStack はgojaのスタックフレームをTypeScriptの位置に変換する
+func (p *Program) tsStack(frames []goja.StackFrame) []scripting.StackFrame {
+	stack := make([]scripting.StackFrame, 0, len(frames))
+	for _, f := range frames {
+		frame := scripting.StackFrame{Function: f.FuncName()}
+		if f.SrcName() != "<native>" {
+			frame.Pos = p.tsPosition(f.Position())
+		}
+		stack = append(stack, frame)
+	}
+	return stack
+}
+
+// runtimeError は実行時のエラーをTypeScriptの位置のスタックトレース付きの診断にする
+//
+// Error以外の値（throw "message" など）が投げられた場合もスタックトレースを付ける。
+func (p *Program) runtimeError(err error) error {
+	var ex *goja.Exception
+	if !errors.As(err, &ex) {
+		return &scripting.Error{
+			Phase: scripting.PhaseRun,
+			Diagnostics: []scripting.Diagnostic{{
+				Engine:   EngineName,
+				Severity: scripting.SeverityError,
+				Code:     scripting.CodeRuntime,
+				Message:  err.Error(),
+				Pos:      scripting.Position{File: p.script.Name},
+			}},
+		}
+	}
+
+	return p.stackError(exceptionMessage(ex), p.tsStack(ex.Stack()))
+}
+
+// stackError はスタックトレースの先頭のスクリプト上のフレームを位置にした実行時のエラーを返す
+func (p *Program) stackError(msg string, stack []scripting.StackFrame) error {
+	d := scripting.Diagnostic{
+		Engine:   EngineName,
+		Severity: scripting.SeverityError,
+		Code:     scripting.CodeRuntime,
+		Message:  msg,
+		Stack:    stack,
+	}
+	// 先頭のスクリプト上のフレームをエラーの位置にする
+	for _, f := range d.Stack {
+		if f.Pos.IsValid() {
+			d.Pos = f.Pos
+			d.CodeFrame = p.sources.codeFrame(f.Pos)
+			break
+		}
+	}
+	if !d.Pos.IsValid() {
+		d.Pos = scripting.Position{File: p.script.Name}
+	}
+	return &scripting.Error{Phase: scripting.PhaseRun, Diagnostics: []scripting.Diagnostic{d}}
+}
+
+// stackLine はgojaのErrorの stack プロパティの1行（"\tat name (file:line:col(pc))"）
+var stackLine = regexp.MustCompile(`^at (?:(.*) \()?(.*):(\d+):(\d+)\(\d+\)\)?$`)
+
+// errorStack はErrorの stack プロパティをTypeScriptの位置のスタックフレームにする
+//
+// Promiseのrejectなど goja.Exception のないErrorの位置を求めるのに使う。
+// Error以外の値や stack のないErrorは nil を返す。
+func (p *Program) errorStack(val goja.Value) []scripting.StackFrame {
+	obj, ok := errorObject(val)
+	if !ok {
+		return nil
+	}
+	v := obj.Get("stack")
+	if v == nil || goja.IsUndefined(v) {
+		return nil
+	}
+	var stack []scripting.StackFrame
+	for _, line := range strings.Split(v.String(), "\n") {
+		line = strings.TrimSpace(line)
+		m := stackLine.FindStringSubmatch(line)
+		if m == nil {
+			// ネイティブ関数のフレーム（"at name (native)"）
+			if name, ok := strings.CutPrefix(line, "at "); ok {
+				name = strings.TrimSuffix(strings.TrimSuffix(name, "native)"), " (")
+				stack = append(stack, scripting.StackFrame{Function: strings.TrimSuffix(name, "native")})
+			}
+			continue
+		}
+		lineNo, _ := strconv.Atoi(m[3])
+		col, _ := strconv.Atoi(m[4])
+		stack = append(stack, scripting.StackFrame{
+			Function: m[1],
+			Pos:      p.tsPosition(file.Position{Filename: m[2], Line: lineNo, Column: col}),
+		})
+	}
+	return stack
+}
+
+// exceptionMessage は投げられた値からメッセージを作る（"TypeError: ..." の形）
+func exceptionMessage(ex *goja.Exception) string {
+	msg := valueMessage(ex.Value())
+	if _, isError := errorObject(ex.Value()); isError {
+		return msg
+	}
+	return "Uncaught " + msg
+}
+
+// errorObject はvalがErrorオブジェクトかを返す
+func errorObject(val goja.Value) (*goja.Object, bool) {
+	obj, ok := val.(*goja.Object)
+	return obj, ok && obj.ClassName() == "Error"
+}
+
+// valueMessage はErrorなら "name: message"、それ以外は値をJSONで表した文字列を返す
+func valueMessage(val goja.Value) string {
+	if obj, ok := errorObject(val); ok {
+		name := obj.Get("name")
+		msg := obj.Get("message")
+		switch {
+		case msg == nil || msg.String() == "":
+			return name.String()
+		case name == nil || name.String() == "":
+			return msg.String()
+		default:
+			return fmt.Sprintf("%s: %s", name, msg)
+		}
+	}
+
+	// Error以外の値は値そのものを表示する
+	if val == nil || goja.IsUndefined(val) {
+		return "undefined"
+	}
+	if b, err := json.Marshal(val.Export()); err == nil {
+		return string(b)
+	}
+	return val.String()
+}
+
+// scriptError は位置の分からない1件の診断のエラーを返す
+func (p *Program) scriptError(code, msg string) error {
+	return &scripting.Error{
+		Phase: scripting.PhaseRun,
+		Diagnostics: []scripting.Diagnostic{{
+			Engine:   EngineName,
+			Severity: scripting.SeverityError,
+			Code:     code,
+			Message:  msg,
+			Pos:      scripting.Position{File: p.script.Name},
+		}},
+	}
+}
+
+// compileError はgojaのコンパイルエラーをTypeScriptの位置の診断にする
+func compileError(script scripting.Script, sources sourceFiles, smap *sourcemap.Consumer, err error) error {
+	d := scripting.Diagnostic{
+		Engine:   EngineName,
+		Severity: scripting.SeverityError,
+		Code:     scripting.CodeCompile,
+		Message:  err.Error(),
+		Pos:      scripting.Position{File: script.Name},
+	}
+
+	var syntaxErr *goja.CompilerSyntaxError
+	if errors.As(err, &syntaxErr) && syntaxErr.File != nil {
+		d.Code = scripting.CodeSyntax
+		d.Message = "SyntaxError: " + syntaxErr.Message
+		d.Pos = tsPosition(smap, sources, script.Name, syntaxErr.File.Position(syntaxErr.Offset))
+		d.CodeFrame = sources.codeFrame(d.Pos)
+	}
+	return &scripting.Error{Phase: scripting.PhaseCompile, Diagnostics: []scripting.Diagnostic{d}}
+}
+
+// tsPosition はJavaScriptの位置をsourcemapで元のファイルの位置に変換する
+//
+// importしたモジュールの位置はそのファイル名（モジュールのルートからのパス）になる。
+func tsPosition(smap *sourcemap.Consumer, sources sourceFiles, filename string, pos file.Position) scripting.Position {
+	if smap == nil {
+		return scripting.Position{File: filename}
+	}
+	// gojaの列は1始まり、sourcemapの列は0始まり
+	source, _, line, col, ok := smap.Source(pos.Line, pos.Column-1)
+	if !ok {
+		return scripting.Position{File: filename}
+	}
+	name := sourceName(source, filename)
+	return scripting.Position{File: name, Line: line, Column: runeColumn(sources[name], line, col)}
+}
+
+// runeColumn はsourcemapの列（0始まり、UTF-16のコード単位）を文字単位の列（1始まり）にする
+func runeColumn(source string, line, col int) int {
+	for i, text := range strings.Split(source, "\n") {
+		if i+1 != line {
+			continue
+		}
+		n, units := 0, 0
+		for _, r := range text {
+			if units >= col {
+				return n + 1
+			}
+			units += utf16.RuneLen(r)
+			n++
+		}
+		// 行末より後ろは1文字1コード単位として数える
+		return n + (col - units) + 1
+	}
+	return col + 1
+}
+
+// esbuildDiagnostics はesbuildのメッセージを位置・コードフレーム・補足付きの診断にする
+func esbuildDiagnostics(script scripting.Script, sources sourceFiles, severity scripting.Severity, msgs []api.Message) []scripting.Diagnostic {
+	diagnostics := make([]scripting.Diagnostic, 0, len(msgs))
+	for _, msg := range msgs {
+		// esbuildのエラーのほとんどはIDのない構文エラー
+		code := msg.ID
+		switch {
+		case msg.PluginName == moduleNamespace:
+			// import を解決できなかった
+			code = scripting.CodeLoad
+		case code == "" && severity == scripting.SeverityError:
+			code = scripting.CodeSyntax
+		}
+		d := scripting.Diagnostic{
+			Engine:   EngineName,
+			Severity: severity,
+			Code:     code,
+			Message:  msg.Text,
+			Pos:      esbuildPosition(script.Name, msg.Location),
+			End:      esbuildEnd(script.Name, msg.Location),
+		}
+		d.CodeFrame = sources.codeFrame(d.Pos)
+		if msg.Location != nil && msg.Location.Suggestion != "" {
+			d.Notes = append(d.Notes, scripting.Note{
+				Message: fmt.Sprintf("%q に置き換えてください", msg.Location.Suggestion),
+				Pos:     d.Pos,
+			})
+		}
+		for _, note := range msg.Notes {
+			// 位置のない補足（説明だけのもの）は位置を付けない
+			n := scripting.Note{Message: note.Text}
+			if note.Location != nil {
+				n.Pos = esbuildPosition(script.Name, note.Location)
+			}
+			d.Notes = append(d.Notes, n)
+		}
+		diagnostics = append(diagnostics, d)
+	}
+	return diagnostics
+}
+
+// esbuildEnd はesbuildの位置の範囲の終わりを返す（範囲がなければ無効）
+func esbuildEnd(filename string, loc *api.Location) scripting.Position {
+	if loc == nil || loc.Length == 0 || loc.Column+loc.Length > len(loc.LineText) {
+		return scripting.Position{}
+	}
+	end := *loc
+	end.Column += loc.Length
+	return esbuildPosition(filename, &end)
+}
+
+// esbuildPosition はesbuildの位置（列は0始まりのバイト数）をPositionにする
+func esbuildPosition(filename string, loc *api.Location) scripting.Position {
+	if loc == nil {
+		return scripting.Position{File: filename}
+	}
+	if loc.File != "" {
+		filename = sourceName(loc.File, filename)
+	}
+	col := loc.Column
+	if col <= len(loc.LineText) {
+		col = utf8.RuneCountInString(loc.LineText[:col])
+	}
+	return scripting.Position{File: filename, Line: loc.Line, Column: col + 1}
+}
@@ -0,0 +1,109 @@
+package typescript
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/suinplayground/golang-embedded-scripting/scripting"
+)
+
+func TestEsbuildDiagnostics(t *testing.T) {
+	pos := func(file string, line, column int) scripting.Position {
+		return scripting.Position{File: file, Line: line, Column: column}
+	}
+	const head = "export default function transform(items: unknown[]) {\n"
+	tests := []struct {
+		name     string
+		source   string
+		severity scripting.Severity
+		code     string
+		message  string
+		pos, end scripting.Position
+		notes    []scripting.Note
+		// frame はコードフレームのエラーの行
+		frame string
+	}{
+		{
+			name:     "構文エラー",
+			source:   head + "  const x = ;\n  return items;\n}\n",
+			severity: scripting.SeverityError,
+			code:     scripting.CodeSyntax,
+			message:  `Unexpected ";"`,
+			pos:      pos("main.ts", 2, 13),
+			end:      pos("main.ts", 2, 14),
+			frame:    "→ 2 |   const x = ;",
+		},
+		{
+			name:     "補足の位置",
+			source:   head + "  let a = 1;\n  let a = 2;\n  return items;\n}\n",
+			severity: scripting.SeverityError,
+			code:     scripting.CodeSyntax,
+			message:  `The symbol "a" has already been declared`,
+			pos:      pos("main.ts", 3, 7),
+			end:      pos("main.ts", 3, 8),
+			notes:    []scripting.Note{{Message: `The symbol "a" was originally declared here:`, Pos: pos("main.ts", 2, 7)}},
+			frame:    "→ 3 |   let a = 2;",
+		},
+		{
+			name:     "import したモジュールの列は文字単位",
+			source:   "import { v } from \"./lib\";\n" + head + "  return items.concat(v);\n}\n",
+			severity: scripting.SeverityError,
+			code:     scripting.CodeSyntax,
+			message:  `Unexpected ";"`,
+			pos:      pos("lib.ts", 1, 25),
+			end:      pos("lib.ts", 1, 26),
+			frame:    `→ 1 | export const v = "名前" + ;`,
+		},
+		{
+			name:     "警告のIDをコードにする",
+			source:   head + "  const n = items.length / 0;\n  if (n === NaN) return [];\n  return items;\n}\n",
+			severity: scripting.SeverityWarning,
+			code:     "equals-nan",
+			message:  `Comparison with NaN using the "===" operator here is always false`,
+			pos:      pos("main.ts", 3, 9),
+			end:      pos("main.ts", 3, 12),
+			notes: []scripting.Note{{Message: `Floating-point equality is defined such that NaN is never equal to anything, so "x === NaN" always returns false. ` +
+				`You need to use "Number.isNaN(x)" instead to test for NaN.`}},
+			frame: "→ 3 |   if (n === NaN) return [];",
+		},
+	}
+	fsys := fstest.MapFS{"lib.ts": {Data: []byte("export const v = \"名前\" + ;\n")}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			var diags []scripting.Diagnostic
+			program, err := New(WithModules(fsys)).Compile(ctx, scripting.Script{Name: "main.ts", Source: tt.source})
+			if err == nil {
+				result, err := program.Run(ctx, nil)
+				if err != nil {
+					t.Fatalf("Run: %v", err)
+				}
+				diags = result.Diagnostics
+			} else {
+				diags = scripting.Diagnostics(err)
+			}
+			if len(diags) != 1 {
+				t.Fatalf("診断が %d 件です: %+v", len(diags), diags)
+			}
+			d := diags[0]
+			if d.Engine != EngineName || d.Severity != tt.severity || d.Code != tt.code {
+				t.Errorf("Engine, Severity, Code = %q, %v, %q, want %q, %v, %q", d.Engine, d.Severity, d.Code, EngineName, tt.severity, tt.code)
+			}
+			if d.Message != tt.message {
+				t.Errorf("Message = %q, want %q", d.Message, tt.message)
+			}
+			if d.Pos != tt.pos || d.End != tt.end {
+				t.Errorf("範囲 = %+v-%+v, want %+v-%+v", d.Pos, d.End, tt.pos, tt.end)
+			}
+			if !reflect.DeepEqual(d.Notes, tt.notes) {
+				t.Errorf("Notes = %+v, want %+v", d.Notes, tt.notes)
+			}
+			if !strings.Contains(d.CodeFrame, tt.frame+"\n") {
+				t.Errorf("CodeFrame に %q がありません:\n%s", tt.frame, d.CodeFrame)
+			}
+		})
+	}
+}
@@ -0,0 +1,284 @@
+// Package typescript はesbuildでトランスパイルしたTypeScriptをgojaで実行するエンジン
+package typescript
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"github.com/dop251/goja"
+	"github.com/go-sourcemap/sourcemap"
+
+	"github.com/suinplayground/golang-embedded-scripting/kube"
+	"github.com/suinplayground/golang-embedded-scripting/scripting"
+)
+
+// EngineName はこのエンジンの名前
+const EngineName = "typescript"
+
+// LineComment はヘッダーでパラメータ（@param）を宣言する行コメント
+const LineComment = "//"
+
+// Engine はTypeScriptスクリプトを実行するscripting.Engineの実装
+//
+// スクリプトは export default function transform(items, ctx)（または
+// export function transform）を定義し、その戻り値を結果として返す。
+type Engine struct {
+	logHandler slog.Handler
+	modules    fs.FS
+	packages   map[string]bool
+	programs   *scripting.Cache
+}
+
+// Option はEngineの設定を変更する
+type Option func(*Engine)
+
+// WithLogHandler はスクリプトのconsole出力を送るslogのハンドラを設定する
+//
+// 設定しなければログは scripting.Result.Logs に記録されるだけになる。
+func WithLogHandler(h slog.Handler) Option {
+	return func(e *Engine) {
+		e.logHandler = h
+	}
+}
+
+// WithCache はコンパイル済みのプログラムをcに保存し、同じスクリプトのコンパイルで使い回す
+//
+// esbuildのバンドル・sourcemap・gojaのProgramをまとめてキャッシュする。
+// importしたモジュールが変わっていればコンパイルし直す。
+func WithCache(c *scripting.Cache) Option {
+	return func(e *Engine) {
+		e.programs = c
+	}
+}
+
+// New はTypeScriptエンジンを作成する
+func New(opts ...Option) *Engine {
+	e := &Engine{}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+func (e *Engine) Name() string { return EngineName }
+
+// Compile はTypeScriptをJavaScriptにトランスパイル（バンドル）し、gojaのProgramにコンパイルする
+//
+// esbuildとgojaのコンパイルは途中で中断できないので、ctxは各段階の前後で確認する。
+func (e *Engine) Compile(ctx context.Context, script scripting.Script) (scripting.Program, error) {
+	if ctx.Err() != nil {
+		return nil, scripting.NewTimeout(ctx, EngineName, script.Name, scripting.PhaseCompile)
+	}
+
+	key := e.cacheKey(script)
+	if e.programs != nil {
+		cached, ok := e.programs.Get(key, func(v interface{}) bool {
+			return v.(*Program).modules.Unchanged(e.modules)
+		})
+		if ok {
+			return cached.(*Program).withLogHandler(e.logHandler), nil
+		}
+	}
+
+	params, err := scripting.DeclaredParams(EngineName, script, LineComment)
+	if err != nil {
+		return nil, err
+	}
+
+	// TypeScript→JavaScriptトランスパイル（importしたモジュールもまとめる）
+	jsCode, sourceMapData, sources, warnings, err := e.bundle(script)
+	if err != nil {
+		return nil, err
+	}
+
+	// sourcemapをパース
+	// esbuildが出力するsourcemapでパースに失敗するのは、exportのない
+	// スクリプトなどでマッピングが空の場合だけなので、位置を変換せずに続ける
+	smap, err := sourcemap.Parse("", []byte(sourceMapData))
+	if err != nil {
+		smap = nil
+	}
+
+	if ctx.Err() != nil {
+		return nil, scripting.NewTimeout(ctx, EngineName, script.Name, scripting.PhaseCompile)
+	}
+
+	program, err := goja.Compile(jsFilename(script.Name), jsCode, false)
+	if err != nil {
+		return nil, compileError(script, sources, smap, err)
+	}
+
+	p := &Program{
+		script:      script,
+		params:      params,
+		logHandler:  e.logHandler,
+		program:     program,
+		smap:        smap,
+		sources:     sources,
+		modules:     scripting.NewModuleDigests(script, sources),
+		diagnostics: warnings,
+	}
+	if e.programs != nil {
+		e.programs.Put(key, p)
+	}
+	return p, nil
+}
+
+// cacheKey はスクリプトと、コンパイル結果が変わるエンジンの設定（モジュールの読み込み元・許可したパッケージ）のキー
+func (e *Engine) cacheKey(script scripting.Script) string {
+	packages := make([]string, 0, len(e.packages))
+	for name := range e.packages {
+		packages = append(packages, name)
+	}
+	sort.Strings(packages)
+	return scripting.CacheKey(EngineName, script, scripting.ModulesKey(e.modules), strings.Join(packages, ","))
+}
+
+// Program はコンパイル済みのTypeScriptスクリプト
+type Program struct {
+	script     scripting.Script
+	params     []scripting.Param
+	logHandler slog.Handler
+	program    *goja.Program
+	smap       *sourcemap.Consumer
+	sources    sourceFiles
+	// modules はimportしたモジュールの内容のハッシュ（キャッシュの確認用）
+	modules     scripting.ModuleDigests
+	diagnostics []scripting.Diagnostic
+}
+
+// withLogHandler はログの送り先だけを変えたProgramを返す（キャッシュしたProgramは共有する）
+func (p *Program) withLogHandler(h slog.Handler) *Program {
+	cp := *p
+	cp.logHandler = h
+	return &cp
+}
+
+// Run はgojaの新しいランタイムでスクリプトを実行する
+func (p *Program) Run(ctx context.Context, objects []kube.Object, opts ...scripting.RunOption) (*scripting.Result, error) {
+	if ctx.Err() != nil {
+		return nil, scripting.NewTimeout(ctx, EngineName, p.script.Name, scripting.PhaseRun)
+	}
+	runOpts := scripting.NewRunOptions(opts...)
+
+	// 宣言に合わせてパラメータを確認する（スクリプトを実行する前に）
+	runCtx := runOpts.Context(p.script.Name)
+	var err error
+	if runCtx.Params, err = scripting.ResolveParams(EngineName, p.script, p.params, runCtx.Params); err != nil {
+		return nil, err
+	}
+
+	limits := runOpts.Limits
+	logHandler := runOpts.LogHandler
+	if logHandler == nil {
+		logHandler = p.logHandler
+	}
+	logs := scripting.NewLogCollector(ctx, EngineName, p.script.Name, logHandler)
+
+	// メモリ割り当ての上限を超えたらctxが終了する
+	ctx, stopWatch := limits.WatchAlloc(ctx, EngineName, p.script.Name)
+	defer stopWatch()
+
+	vm := goja.New()
+	if limits.MaxCallStackSize > 0 {
+		vm.SetMaxCallStackSize(limits.MaxCallStackSize)
+	}
+
+	// ctxが終了したら実行中のスクリプトを中断する
+	stop := context.AfterFunc(ctx, func() {
+		vm.Interrupt(context.Cause(ctx))
+	})
+	defer stop()
+
+	// consoleを実装（ログはすべてlogsに記録する）
+	console := newConsole(vm, logs, func() []scripting.StackFrame {
+		return p.tsStack(vm.CaptureCallStack(0, nil))
+	})
+	vm.Set("console", console.object())
+
+	// transformに渡すctx（ctx.logもconsoleと同じくlogsに記録する）
+	scriptCtx, err := newContext(vm, runCtx, console)
+	if err != nil {
+		return nil, err
+	}
+
+	// モジュールのトップレベルを実行してエントリポイントを取り出す
+	if _, err := vm.RunProgram(p.program); err != nil {
+		return nil, p.runError(ctx, limits, err)
+	}
+	transform, err := p.entryPoint(vm)
+	if err != nil {
+		return nil, err
+	}
+
+	// オブジェクトを汎用値にしてJavaScriptに渡す
+	result, err := transform(goja.Undefined(), vm.ToValue(kube.Generic(objects)), scriptCtx)
+	if err != nil {
+		return nil, p.runError(ctx, limits, err)
+	}
+	result, err = p.settle(result)
+	if err != nil {
+		return nil, err
+	}
+
+	// 結果をGoのオブジェクトに変換
+	outputs, err := kube.ObjectsFromGeneric(result.Export())
+	if err != nil {
+		return nil, err
+	}
+	if err := limits.CheckOutput(EngineName, p.script.Name, outputs); err != nil {
+		return nil, err
+	}
+
+	return &scripting.Result{
+		Objects:     outputs,
+		Diagnostics: p.diagnostics,
+		Logs:        logs.Records(),
+	}, nil
+}
+
+// newContext はスクリプトに渡すctxオブジェクトを作成する
+func newContext(vm *goja.Runtime, c scripting.Context, console *console) (*goja.Object, error) {
+	values, err := c.Generic()
+	if err != nil {
+		return nil, err
+	}
+	obj := vm.NewObject()
+	for k, v := range values {
+		obj.Set(k, v)
+	}
+	obj.Set("log", console.logger())
+	return obj, nil
+}
+
+// runError は実行時のエラーを中断・上限超過・スタックトレース付きの診断のいずれかにする
+func (p *Program) runError(ctx context.Context, limits scripting.Limits, err error) error {
+	var interrupted *goja.InterruptedError
+	if errors.As(err, &interrupted) {
+		return scripting.Interrupted(ctx, EngineName, p.script.Name, scripting.PhaseRun)
+	}
+	var overflow *goja.StackOverflowError
+	if errors.As(err, &overflow) {
+		return &scripting.LimitError{
+			Engine: EngineName,
+			Script: p.script.Name,
+			Limit:  scripting.LimitCallStack,
+			Max:    uint64(limits.MaxCallStackSize),
+			Used:   uint64(limits.MaxCallStackSize) + 1,
+		}
+	}
+	// エラーをTypeScriptの位置のスタックトレースに変換
+	return p.runtimeError(err)
+}
+
+// トランスパイル後のJavaScriptのファイル名（"vpc-processor.ts" → "vpc-processor.js"）
+func jsFilename(name string) string {
+	if name == "" {
+		return "script.js"
+	}
+	return strings.TrimSuffix(name, ".ts") + ".js"
+}
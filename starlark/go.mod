@@ -1,7 +0,0 @@
-module github.com/suinplayground/golang-embedded-scripting/starlark
-
-go 1.23
-
-require go.starlark.net v0.0.0-20250906160240-bf296ed553ea
-
-require golang.org/x/sys v0.0.0-20220715151400-c0bba94af5f8 // indirect
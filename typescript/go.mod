@@ -1,16 +0,0 @@
-module github.com/suinplayground/golang-embedded-scripting/typescript
-
-go 1.23
-
-require (
-	github.com/dop251/goja v0.0.0-20240927123429-241b342198c2
-	github.com/evanw/esbuild v0.25.10
-	github.com/go-sourcemap/sourcemap v2.1.4+incompatible
-)
-
-require (
-	github.com/dlclark/regexp2 v1.11.4 // indirect
-	github.com/google/pprof v0.0.0-20230207041349-798e818bf904 // indirect
-	golang.org/x/sys v0.0.0-20220722155257-8c9f86f7a55f // indirect
-	golang.org/x/text v0.3.8 // indirect
-)